@@ -1,5 +1,7 @@
 package esbuilder
 
+import "fmt"
+
 type aggs struct {
 	Name      string
 	TermsItem query
@@ -9,8 +11,10 @@ type aggs struct {
 }
 
 type aggsTerms struct {
-	Field string `json:"field"`
-	Size  int    `json:"size,omitempty"`
+	Field         string                       `json:"field"`
+	Size          int                          `json:"size,omitempty"`
+	SubAggsItems  []*aggs                      `json:"-"`
+	BucketScripts map[string]*aggsBucketScript `json:"-"`
 }
 
 type aggsAvg struct {
@@ -25,6 +29,13 @@ type aggsMin struct {
 	Field string `json:"field"`
 }
 
+// aggsBucketScript is a pipeline aggregation, computed per bucket of the
+// terms aggregation it is added to with aggsTerms.BucketScript.
+type aggsBucketScript struct {
+	BucketsPath map[string]string
+	Script      *script
+}
+
 func NewAggsQuery(name string) *aggs {
 	if name == "" {
 		return nil
@@ -95,9 +106,52 @@ func NewAggsTerm(field string, size int) *aggsTerms {
 	return &aggsTerms{Field: field, Size: size}
 }
 
+// SubAggs adds aggregations computed per bucket.
+func (a *aggsTerms) SubAggs(subAggs ...*aggs) *aggsTerms {
+	a.SubAggsItems = append(a.SubAggsItems, subAggs...)
+	return a
+}
+
+// BucketScript adds the pipeline aggregation name computed per bucket from
+// the sub-aggregations.
+func (a *aggsTerms) BucketScript(name string, bucketScript *aggsBucketScript) *aggsTerms {
+	if a.BucketScripts == nil {
+		a.BucketScripts = make(map[string]*aggsBucketScript)
+	}
+	a.BucketScripts[name] = bucketScript
+	return a
+}
+
 func (a *aggsTerms) Build() (any, error) {
 	source := make(map[string]any)
 	source["terms"] = a
+	if len(a.SubAggsItems) == 0 && len(a.BucketScripts) == 0 {
+		return source, nil
+	}
+	subAggs := make(map[string]any)
+	for _, sub := range a.SubAggsItems {
+		if sub == nil {
+			return nil, fmt.Errorf("terms %s: sub-aggregation must not be nil", a.Field)
+		}
+		src, err := sub.Build()
+		if err != nil {
+			return nil, err
+		}
+		for name, agg := range src.(map[string]any) {
+			subAggs[name] = agg
+		}
+	}
+	for name, bucketScript := range a.BucketScripts {
+		if bucketScript == nil {
+			return nil, fmt.Errorf("terms %s: bucket_script %s must not be nil", a.Field, name)
+		}
+		src, err := bucketScript.Build()
+		if err != nil {
+			return nil, err
+		}
+		subAggs[name] = src
+	}
+	source["aggs"] = subAggs
 	return source, nil
 }
 
@@ -136,3 +190,22 @@ func (a *aggsMin) Build() (any, error) {
 	source["min"] = a
 	return source, nil
 }
+
+func NewAggsBucketScript(bucketsPath map[string]string, script *script) *aggsBucketScript {
+	return &aggsBucketScript{BucketsPath: bucketsPath, Script: script}
+}
+func (a *aggsBucketScript) Build() (any, error) {
+	if len(a.BucketsPath) == 0 || a.Script == nil {
+		return nil, fmt.Errorf("bucket_script: buckets_path and script must be set")
+	}
+	script, err := a.Script.Build()
+	if err != nil {
+		return nil, err
+	}
+	source := make(map[string]any)
+	source["bucket_script"] = map[string]any{
+		"buckets_path": a.BucketsPath,
+		"script":       script,
+	}
+	return source, nil
+}
@@ -0,0 +1,223 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/indices-aliases.html
+type alias struct {
+	name          string
+	filterItem    query
+	routing       string
+	indexRouting  string
+	searchRouting string
+	isWriteIndex  *bool
+	isHidden      *bool
+}
+
+// NewAlias creates and initializes a new alias definition.
+func NewAlias(name string) *alias {
+	return &alias{name: name}
+}
+
+// Filter restricts the documents visible through the alias.
+func (a *alias) Filter(filter query) *alias {
+	a.filterItem = filter
+	return a
+}
+
+// Routing routes both indexing and search operations.
+func (a *alias) Routing(routing string) *alias {
+	a.routing = routing
+	return a
+}
+
+// IndexRouting routes indexing operations.
+func (a *alias) IndexRouting(routing string) *alias {
+	a.indexRouting = routing
+	return a
+}
+
+// SearchRouting routes search operations.
+func (a *alias) SearchRouting(routing string) *alias {
+	a.searchRouting = routing
+	return a
+}
+
+// IsWriteIndex makes the index the write index of the alias.
+func (a *alias) IsWriteIndex(isWriteIndex bool) *alias {
+	a.isWriteIndex = &isWriteIndex
+	return a
+}
+
+// IsHidden hides the alias from wildcard expressions.
+func (a *alias) IsHidden(isHidden bool) *alias {
+	a.isHidden = &isHidden
+	return a
+}
+
+// Build returns the alias definition, without its name.
+func (a *alias) Build() (interface{}, error) {
+	if a.name == "" {
+		return nil, fmt.Errorf("alias: name must be set")
+	}
+	source := make(map[string]interface{})
+	if a.filterItem != nil {
+		src, err := a.filterItem.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["filter"] = src
+	}
+	if a.routing != "" {
+		source["routing"] = a.routing
+	}
+	if a.indexRouting != "" {
+		source["index_routing"] = a.indexRouting
+	}
+	if a.searchRouting != "" {
+		source["search_routing"] = a.searchRouting
+	}
+	if a.isWriteIndex != nil {
+		source["is_write_index"] = *a.isWriteIndex
+	}
+	if a.isHidden != nil {
+		source["is_hidden"] = *a.isHidden
+	}
+	return source, nil
+}
+
+// buildAliases returns the aliases section of index and template bodies.
+func buildAliases(aliases []*alias) (map[string]interface{}, error) {
+	source := make(map[string]interface{}, len(aliases))
+	for _, a := range aliases {
+		src, err := a.Build()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := source[a.name]; ok {
+			return nil, fmt.Errorf("alias: duplicate alias %s", a.name)
+		}
+		source[a.name] = src
+	}
+	return source, nil
+}
+
+// aliasAction is one action of an _aliases request.
+type aliasAction struct {
+	action string
+	index  string
+	alias  *alias
+}
+
+// NewAddAliasAction adds alias to index. index may be a pattern.
+func NewAddAliasAction(index string, alias *alias) *aliasAction {
+	return &aliasAction{action: "add", index: index, alias: alias}
+}
+
+// NewRemoveAliasAction removes the alias name from index.
+func NewRemoveAliasAction(index, name string) *aliasAction {
+	return &aliasAction{action: "remove", index: index, alias: NewAlias(name)}
+}
+
+// NewRemoveIndexAction deletes index as part of the atomic alias change.
+func NewRemoveIndexAction(index string) *aliasAction {
+	return &aliasAction{action: "remove_index", index: index}
+}
+
+func (a *aliasAction) Build() (interface{}, error) {
+	if a.index == "" {
+		return nil, fmt.Errorf("alias %s: index must be set", a.action)
+	}
+	params := map[string]interface{}{
+		"index": a.index,
+	}
+	switch a.action {
+	case "add":
+		if a.alias == nil {
+			return nil, fmt.Errorf("alias add: alias must be set")
+		}
+		src, err := a.alias.Build()
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range src.(map[string]interface{}) {
+			params[key] = value
+		}
+		params["alias"] = a.alias.name
+	case "remove":
+		if a.alias == nil || a.alias.name == "" {
+			return nil, fmt.Errorf("alias remove: alias must be set")
+		}
+		params["alias"] = a.alias.name
+	}
+	return map[string]interface{}{
+		a.action: params,
+	}, nil
+}
+
+// aliases is an _aliases request applying its actions atomically.
+type aliases struct {
+	actions []*aliasAction
+}
+
+// NewAliases creates and initializes a new _aliases request.
+func NewAliases(actions ...*aliasAction) *aliases {
+	return &aliases{actions: append(make([]*aliasAction, 0), actions...)}
+}
+
+// Add adds actions to the request.
+func (a *aliases) Add(actions ...*aliasAction) *aliases {
+	a.actions = append(a.actions, actions...)
+	return a
+}
+
+// Build returns the body of the _aliases request.
+func (a *aliases) Build() (interface{}, error) {
+	if len(a.actions) == 0 {
+		return nil, fmt.Errorf("aliases: at least one action must be added")
+	}
+	actions := make([]interface{}, 0, len(a.actions))
+	for _, action := range a.actions {
+		src, err := action.Build()
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, src)
+	}
+	return map[string]interface{}{
+		"actions": actions,
+	}, nil
+}
+
+// Do sends the _aliases request.
+func (a *aliases) Do(ctx context.Context, t Transport) error {
+	src, err := a.Build()
+	if err != nil {
+		return err
+	}
+	_, err = perform(ctx, t, "POST", "_aliases", src)
+	return err
+}
+
+// GetAliasIndices returns the indices the alias name points to.
+func GetAliasIndices(ctx context.Context, t Transport, name string) ([]string, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	data, err := perform(ctx, t, "GET", "_alias/"+url.PathEscape(name), nil)
+	if err != nil {
+		if re, ok := err.(*ResponseError); ok && re.Status == 404 {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	resp := make(map[string]interface{})
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return sortedMapKeys(resp), nil
+}
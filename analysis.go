@@ -0,0 +1,309 @@
+package esbuilder
+
+import (
+	"fmt"
+	"sort"
+)
+
+// builtinAnalyzers, builtinTokenizers, builtinTokenFilters and
+// builtinCharFilters list the names usable without defining them.
+var (
+	builtinAnalyzers    = []string{"standard", "simple", "whitespace", "stop", "keyword", "pattern", "fingerprint", "english"}
+	builtinTokenizers   = []string{"standard", "letter", "lowercase", "whitespace", "uax_url_email", "classic", "ngram", "edge_ngram", "keyword", "pattern", "simple_pattern", "char_group", "path_hierarchy"}
+	builtinTokenFilters = []string{"lowercase", "uppercase", "stop", "edge_ngram", "ngram", "asciifolding", "trim", "unique", "reverse", "porter_stem", "stemmer", "kstem", "word_delimiter", "word_delimiter_graph", "shingle", "length", "truncate", "elision", "cjk_width", "cjk_bigram", "decimal_digit", "apostrophe", "classic", "flatten_graph", "remove_duplicates"}
+	builtinCharFilters  = []string{"html_strip", "mapping", "pattern_replace"}
+)
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/analysis.html
+type analysis struct {
+	analyzers    map[string]*analysisComponent
+	tokenizers   map[string]*analysisComponent
+	tokenFilters map[string]*analysisComponent
+	charFilters  map[string]*analysisComponent
+	normalizers  map[string]*analysisComponent
+}
+
+// NewAnalysis creates and initializes a new analysis section, to be set
+// with indexSettings.Analysis.
+func NewAnalysis() *analysis {
+	return &analysis{
+		analyzers:    make(map[string]*analysisComponent),
+		tokenizers:   make(map[string]*analysisComponent),
+		tokenFilters: make(map[string]*analysisComponent),
+		charFilters:  make(map[string]*analysisComponent),
+		normalizers:  make(map[string]*analysisComponent),
+	}
+}
+
+// Analyzer defines the analyzer name.
+func (a *analysis) Analyzer(name string, analyzer *analysisComponent) *analysis {
+	a.analyzers[name] = analyzer
+	return a
+}
+
+// Tokenizer defines the tokenizer name.
+func (a *analysis) Tokenizer(name string, tokenizer *analysisComponent) *analysis {
+	a.tokenizers[name] = tokenizer
+	return a
+}
+
+// TokenFilter defines the token filter name.
+func (a *analysis) TokenFilter(name string, filter *analysisComponent) *analysis {
+	a.tokenFilters[name] = filter
+	return a
+}
+
+// CharFilter defines the char filter name.
+func (a *analysis) CharFilter(name string, filter *analysisComponent) *analysis {
+	a.charFilters[name] = filter
+	return a
+}
+
+// Normalizer defines the normalizer name.
+func (a *analysis) Normalizer(name string, normalizer *analysisComponent) *analysis {
+	a.normalizers[name] = normalizer
+	return a
+}
+
+// Build returns the map for the analysis section. Names referenced by
+// custom analyzers and normalizers must be built in or defined.
+func (a *analysis) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	sections := []struct {
+		name       string
+		components map[string]*analysisComponent
+	}{
+		{"analyzer", a.analyzers},
+		{"tokenizer", a.tokenizers},
+		{"filter", a.tokenFilters},
+		{"char_filter", a.charFilters},
+		{"normalizer", a.normalizers},
+	}
+	for _, section := range sections {
+		if len(section.components) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(section.components))
+		for name := range section.components {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		built := make(map[string]interface{}, len(names))
+		for _, name := range names {
+			component := section.components[name]
+			src, err := component.Build()
+			if err != nil {
+				return nil, fmt.Errorf("analysis %s %s: %v", section.name, name, err)
+			}
+			if err := a.validateReferences(component); err != nil {
+				return nil, fmt.Errorf("analysis %s %s: %v", section.name, name, err)
+			}
+			built[name] = src
+		}
+		source[section.name] = built
+	}
+	return source, nil
+}
+
+func (a *analysis) validateReferences(c *analysisComponent) error {
+	if c.componentType != "custom" {
+		return nil
+	}
+	if tokenizer, ok := c.params["tokenizer"].(string); ok {
+		if _, defined := a.tokenizers[tokenizer]; !defined && !containsString(builtinTokenizers, tokenizer) {
+			return fmt.Errorf("unknown tokenizer %s", tokenizer)
+		}
+	}
+	for _, filter := range c.filters {
+		if _, defined := a.tokenFilters[filter]; !defined && !containsString(builtinTokenFilters, filter) {
+			return fmt.Errorf("unknown token filter %s", filter)
+		}
+	}
+	for _, filter := range c.charFilters {
+		if _, defined := a.charFilters[filter]; !defined && !containsString(builtinCharFilters, filter) {
+			return fmt.Errorf("unknown char filter %s", filter)
+		}
+	}
+	return nil
+}
+
+// analysisComponent is an analyzer, tokenizer, token filter, char filter
+// or normalizer definition.
+type analysisComponent struct {
+	componentType string
+	params        map[string]interface{}
+	filters       []string
+	charFilters   []string
+}
+
+func newAnalysisComponent(componentType string) *analysisComponent {
+	return &analysisComponent{
+		componentType: componentType,
+		params:        make(map[string]interface{}),
+		filters:       make([]string, 0),
+		charFilters:   make([]string, 0),
+	}
+}
+
+// NewCustomAnalyzer creates a custom analyzer using tokenizer.
+func NewCustomAnalyzer(tokenizer string) *analysisComponent {
+	return newAnalysisComponent("custom").Param("tokenizer", tokenizer)
+}
+
+// NewAnalyzer configures a built-in analyzer type, e.g. standard with
+// stopwords.
+func NewAnalyzer(analyzerType string) *analysisComponent {
+	return newAnalysisComponent(analyzerType)
+}
+
+// NewCustomNormalizer creates a normalizer, which only supports
+// character-level filters such as lowercase.
+func NewCustomNormalizer() *analysisComponent {
+	return newAnalysisComponent("custom")
+}
+
+// NewTokenizer configures a tokenizer type, e.g. standard with
+// max_token_length.
+func NewTokenizer(tokenizerType string) *analysisComponent {
+	return newAnalysisComponent(tokenizerType)
+}
+
+// NewTokenFilter configures a token filter type.
+func NewTokenFilter(filterType string) *analysisComponent {
+	return newAnalysisComponent(filterType)
+}
+
+// NewStopFilter removes stopwords, or "_english_" when none are given.
+func NewStopFilter(stopwords ...string) *analysisComponent {
+	f := NewTokenFilter("stop")
+	if len(stopwords) > 0 {
+		f.Param("stopwords", stopwords)
+	}
+	return f
+}
+
+// NewSynonymFilter adds synonyms in Solr format, e.g. "tv, television"
+// or "i-pod, i pod => ipod".
+func NewSynonymFilter(synonyms ...string) *analysisComponent {
+	return NewTokenFilter("synonym").Param("synonyms", synonyms)
+}
+
+// NewSynonymGraphFilter is NewSynonymFilter for search time analysis of
+// multi-word synonyms.
+func NewSynonymGraphFilter(synonyms ...string) *analysisComponent {
+	return NewTokenFilter("synonym_graph").Param("synonyms", synonyms)
+}
+
+// NewEdgeNgramFilter emits the prefixes of each token from minGram to
+// maxGram characters long.
+func NewEdgeNgramFilter(minGram, maxGram int) *analysisComponent {
+	return NewTokenFilter("edge_ngram").Param("min_gram", minGram).Param("max_gram", maxGram)
+}
+
+// NewEdgeNgramTokenizer emits the prefixes of each word from minGram to
+// maxGram characters long.
+func NewEdgeNgramTokenizer(minGram, maxGram int, tokenChars ...string) *analysisComponent {
+	t := NewTokenizer("edge_ngram").Param("min_gram", minGram).Param("max_gram", maxGram)
+	if len(tokenChars) > 0 {
+		t.Param("token_chars", tokenChars)
+	}
+	return t
+}
+
+// NewCharFilter configures a char filter type.
+func NewCharFilter(filterType string) *analysisComponent {
+	return newAnalysisComponent(filterType)
+}
+
+// NewMappingCharFilter replaces characters with mappings such as "ph => f".
+func NewMappingCharFilter(mappings ...string) *analysisComponent {
+	return NewCharFilter("mapping").Param("mappings", mappings)
+}
+
+// NewPatternReplaceCharFilter replaces matches of the regex pattern.
+func NewPatternReplaceCharFilter(pattern, replacement string) *analysisComponent {
+	return NewCharFilter("pattern_replace").Param("pattern", pattern).Param("replacement", replacement)
+}
+
+// Param sets any parameter of the component.
+func (c *analysisComponent) Param(name string, value interface{}) *analysisComponent {
+	c.params[name] = value
+	return c
+}
+
+// Filter adds token filters, applied in order, to a custom analyzer or
+// normalizer.
+func (c *analysisComponent) Filter(names ...string) *analysisComponent {
+	c.filters = append(c.filters, names...)
+	return c
+}
+
+// CharFilter adds char filters, applied in order, to a custom analyzer or
+// normalizer.
+func (c *analysisComponent) CharFilter(names ...string) *analysisComponent {
+	c.charFilters = append(c.charFilters, names...)
+	return c
+}
+
+// Build returns the map for the component.
+func (c *analysisComponent) Build() (interface{}, error) {
+	if c.componentType == "" {
+		return nil, fmt.Errorf("type must be set")
+	}
+	if (len(c.filters) > 0 || len(c.charFilters) > 0) && c.componentType != "custom" {
+		return nil, fmt.Errorf("%s does not support filter and char_filter", c.componentType)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	source := make(map[string]interface{}, len(c.params)+3)
+	source["type"] = c.componentType
+	for name, value := range c.params {
+		source[name] = value
+	}
+	if len(c.filters) > 0 {
+		source["filter"] = c.filters
+	}
+	if len(c.charFilters) > 0 {
+		source["char_filter"] = c.charFilters
+	}
+	return source, nil
+}
+
+func (c *analysisComponent) validate() error {
+	switch c.componentType {
+	case "edge_ngram", "ngram":
+		minGram, maxGram := int64(1), int64(2)
+		if v, ok := intParam(c.params["min_gram"]); ok {
+			minGram = v
+		}
+		if v, ok := intParam(c.params["max_gram"]); ok {
+			maxGram = v
+		}
+		if minGram < 1 || minGram > maxGram {
+			return fmt.Errorf("%s: min_gram must be in [1, max_gram]", c.componentType)
+		}
+	case "standard", "whitespace":
+		if v, ok := intParam(c.params["max_token_length"]); ok && v < 1 {
+			return fmt.Errorf("%s: max_token_length must be at least 1", c.componentType)
+		}
+	case "synonym", "synonym_graph":
+		_, hasSynonyms := c.params["synonyms"]
+		_, hasPath := c.params["synonyms_path"]
+		if !hasSynonyms && !hasPath {
+			return fmt.Errorf("%s requires synonyms or synonyms_path", c.componentType)
+		}
+	case "mapping":
+		_, hasMappings := c.params["mappings"]
+		_, hasPath := c.params["mappings_path"]
+		if !hasMappings && !hasPath {
+			return fmt.Errorf("mapping requires mappings or mappings_path")
+		}
+	case "pattern_replace":
+		if _, ok := c.params["pattern"]; !ok {
+			return fmt.Errorf("pattern_replace requires pattern")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,556 @@
+package esbuilder
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// englishStopwords is the _english_ stopword list.
+var englishStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "if", "in", "into", "is", "it",
+	"no", "not", "of", "on", "or", "such", "that", "the", "their", "then", "there", "these",
+	"they", "this", "to", "was", "will", "with",
+}
+
+// analyzeToken is a token as returned by the _analyze API.
+type analyzeToken struct {
+	Token       string `json:"token"`
+	StartOffset int    `json:"start_offset"`
+	EndOffset   int    `json:"end_offset"`
+	Type        string `json:"type"`
+	Position    int    `json:"position"`
+}
+
+type (
+	analyzeCharFilter  func(text string) string
+	analyzeTokenizer   func(text []rune) []*analyzeToken
+	analyzeTokenFilter func(tokens []*analyzeToken) []*analyzeToken
+)
+
+// analyzeChain is an analyzer resolved to local implementations.
+type analyzeChain struct {
+	charFilters []analyzeCharFilter
+	tokenizer   analyzeTokenizer
+	filters     []analyzeTokenFilter
+}
+
+func (c *analyzeChain) run(text string) []*analyzeToken {
+	for _, filter := range c.charFilters {
+		text = filter(text)
+	}
+	tokens := c.tokenizer([]rune(text))
+	for _, filter := range c.filters {
+		tokens = filter(tokens)
+	}
+	return tokens
+}
+
+// Analyze previews the tokens produced by analyzer for text without a
+// cluster. Only the standard, whitespace and keyword tokenizers, the
+// lowercase, uppercase, stop, edge_ngram and synonym token filters and the
+// mapping, pattern_replace and html_strip char filters are implemented.
+// Offsets are computed on the char filtered text and synonym graphs are
+// flattened, so results approximate the _analyze API.
+func (a *analysis) Analyze(analyzer, text string) ([]*analyzeToken, error) {
+	chain, err := a.resolveAnalyzer(analyzer)
+	if err != nil {
+		return nil, err
+	}
+	return chain.run(text), nil
+}
+
+// AnalyzeMatchQuery previews the tokens a match query searches for. The
+// analyzer of the query is used, or else fieldAnalyzer, or else standard.
+func (a *analysis) AnalyzeMatchQuery(q *matchQuery, fieldAnalyzer string) ([]*analyzeToken, error) {
+	analyzer := q.analyzer
+	if analyzer == "" {
+		analyzer = fieldAnalyzer
+	}
+	if analyzer == "" {
+		analyzer = "standard"
+	}
+	text, ok := q.text.(string)
+	if !ok {
+		text = fmt.Sprint(q.text)
+	}
+	return a.Analyze(analyzer, text)
+}
+
+// Normalize previews the keyword produced by normalizer for text.
+func (a *analysis) Normalize(normalizer, text string) (string, error) {
+	chain := &analyzeChain{tokenizer: keywordTokenizer}
+	if normalizer == "lowercase" {
+		chain.filters = append(chain.filters, mapTokens(strings.ToLower))
+	} else {
+		c, ok := a.normalizers[normalizer]
+		if !ok {
+			return "", fmt.Errorf("analyze: unknown normalizer %s", normalizer)
+		}
+		if err := a.resolveFilters(chain, c); err != nil {
+			return "", err
+		}
+	}
+	tokens := chain.run(text)
+	if len(tokens) == 0 {
+		return "", nil
+	}
+	return tokens[0].Token, nil
+}
+
+func (a *analysis) resolveAnalyzer(name string) (*analyzeChain, error) {
+	c, ok := a.analyzers[name]
+	if !ok {
+		if !containsString(builtinAnalyzers, name) {
+			return nil, fmt.Errorf("analyze: unknown analyzer %s", name)
+		}
+		c = NewAnalyzer(name)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("analyze: analyzer %s: %v", name, err)
+	}
+	switch c.componentType {
+	case "custom":
+		tokenizerName, _ := c.params["tokenizer"].(string)
+		tokenizer, err := a.resolveTokenizer(tokenizerName)
+		if err != nil {
+			return nil, err
+		}
+		chain := &analyzeChain{tokenizer: tokenizer}
+		if err := a.resolveFilters(chain, c); err != nil {
+			return nil, err
+		}
+		return chain, nil
+	case "standard":
+		chain := &analyzeChain{
+			tokenizer: standardTokenizer(analysisIntParam(c.params, "max_token_length", 255)),
+			filters:   []analyzeTokenFilter{mapTokens(strings.ToLower)},
+		}
+		if _, ok := c.params["stopwords"]; ok {
+			chain.filters = append(chain.filters, stopFilter(c.params))
+		}
+		return chain, nil
+	case "whitespace":
+		return &analyzeChain{tokenizer: whitespaceTokenizer(255)}, nil
+	case "keyword":
+		return &analyzeChain{tokenizer: keywordTokenizer}, nil
+	}
+	return nil, fmt.Errorf("analyze: %s analyzer is not supported locally", c.componentType)
+}
+
+func (a *analysis) resolveTokenizer(name string) (analyzeTokenizer, error) {
+	c, ok := a.tokenizers[name]
+	if !ok {
+		c = NewTokenizer(name)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("analyze: tokenizer %s: %v", name, err)
+	}
+	switch c.componentType {
+	case "standard":
+		return standardTokenizer(analysisIntParam(c.params, "max_token_length", 255)), nil
+	case "whitespace":
+		return whitespaceTokenizer(analysisIntParam(c.params, "max_token_length", 255)), nil
+	case "keyword":
+		return keywordTokenizer, nil
+	}
+	return nil, fmt.Errorf("analyze: %s tokenizer is not supported locally", c.componentType)
+}
+
+func (a *analysis) resolveFilters(chain *analyzeChain, c *analysisComponent) error {
+	for _, name := range c.charFilters {
+		f, ok := a.charFilters[name]
+		if !ok {
+			f = NewCharFilter(name)
+		}
+		filter, err := resolveCharFilter(f)
+		if err != nil {
+			return err
+		}
+		chain.charFilters = append(chain.charFilters, filter)
+	}
+	for _, name := range c.filters {
+		f, ok := a.tokenFilters[name]
+		if !ok {
+			f = NewTokenFilter(name)
+		}
+		var filter analyzeTokenFilter
+		switch f.componentType {
+		case "lowercase":
+			filter = mapTokens(strings.ToLower)
+		case "uppercase":
+			filter = mapTokens(strings.ToUpper)
+		case "stop":
+			filter = stopFilter(f.params)
+		case "edge_ngram":
+			filter = edgeNgramFilter(f.params)
+		case "synonym", "synonym_graph":
+			// Rules are analyzed with the part of the chain before the filter.
+			prefix := &analyzeChain{tokenizer: chain.tokenizer, filters: append([]analyzeTokenFilter(nil), chain.filters...)}
+			var err error
+			if filter, err = synonymFilter(f.params, prefix); err != nil {
+				return fmt.Errorf("analyze: token filter %s: %v", name, err)
+			}
+		default:
+			return fmt.Errorf("analyze: %s token filter is not supported locally", f.componentType)
+		}
+		chain.filters = append(chain.filters, filter)
+	}
+	return nil
+}
+
+func resolveCharFilter(c *analysisComponent) (analyzeCharFilter, error) {
+	switch c.componentType {
+	case "html_strip":
+		tags := regexp.MustCompile(`<[^>]*>`)
+		return func(text string) string {
+			return html.UnescapeString(tags.ReplaceAllString(text, ""))
+		}, nil
+	case "pattern_replace":
+		pattern, _ := c.params["pattern"].(string)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("analyze: pattern_replace: %v", err)
+		}
+		replacement, _ := c.params["replacement"].(string)
+		return func(text string) string {
+			return re.ReplaceAllString(text, replacement)
+		}, nil
+	case "mapping":
+		mappings := analysisStringsParam(c.params, "mappings")
+		type pair struct{ from, to string }
+		pairs := make([]pair, 0, len(mappings))
+		for _, m := range mappings {
+			parts := strings.SplitN(m, "=>", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("analyze: invalid mapping %q", m)
+			}
+			pairs = append(pairs, pair{strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])})
+		}
+		// Longest keys first, so the longest mapping matching wins.
+		sort.SliceStable(pairs, func(i, j int) bool { return len(pairs[i].from) > len(pairs[j].from) })
+		oldnew := make([]string, 0, 2*len(pairs))
+		for _, p := range pairs {
+			oldnew = append(oldnew, p.from, p.to)
+		}
+		replacer := strings.NewReplacer(oldnew...)
+		return replacer.Replace, nil
+	}
+	return nil, fmt.Errorf("analyze: %s char filter is not supported locally", c.componentType)
+}
+
+// standardTokenizer approximates the Unicode text segmentation of the
+// standard tokenizer: words keep inner apostrophes, dots and colons
+// between letters and dots and commas between digits, and each
+// ideographic character is a token.
+func standardTokenizer(maxTokenLength int) analyzeTokenizer {
+	isWord := func(r rune) bool {
+		return (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_') && !isIdeographic(r)
+	}
+	return func(text []rune) []*analyzeToken {
+		tokens := make([]*analyzeToken, 0)
+		for i := 0; i < len(text); {
+			r := text[i]
+			if isIdeographic(r) {
+				tokens = appendToken(tokens, text, i, i+1, "<IDEOGRAPHIC>", maxTokenLength)
+				i++
+				continue
+			}
+			if !isWord(r) {
+				i++
+				continue
+			}
+			start := i
+			numeric := true
+			for i < len(text) {
+				r = text[i]
+				if isWord(r) {
+					if !unicode.IsDigit(r) {
+						numeric = false
+					}
+					i++
+					continue
+				}
+				if i+1 < len(text) && i > start {
+					prev, next := text[i-1], text[i+1]
+					if strings.ContainsRune("'’.:", r) && unicode.IsLetter(prev) && unicode.IsLetter(next) {
+						i++
+						continue
+					}
+					if strings.ContainsRune(".,;", r) && unicode.IsDigit(prev) && unicode.IsDigit(next) {
+						i++
+						continue
+					}
+				}
+				break
+			}
+			tokenType := "<ALPHANUM>"
+			if numeric {
+				tokenType = "<NUM>"
+			}
+			tokens = appendToken(tokens, text, start, i, tokenType, maxTokenLength)
+		}
+		return tokens
+	}
+}
+
+func isIdeographic(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r)
+}
+
+func whitespaceTokenizer(maxTokenLength int) analyzeTokenizer {
+	return func(text []rune) []*analyzeToken {
+		tokens := make([]*analyzeToken, 0)
+		for i := 0; i < len(text); {
+			if unicode.IsSpace(text[i]) {
+				i++
+				continue
+			}
+			start := i
+			for i < len(text) && !unicode.IsSpace(text[i]) {
+				i++
+			}
+			tokens = appendToken(tokens, text, start, i, "word", maxTokenLength)
+		}
+		return tokens
+	}
+}
+
+func keywordTokenizer(text []rune) []*analyzeToken {
+	if len(text) == 0 {
+		return []*analyzeToken{}
+	}
+	return []*analyzeToken{{Token: string(text), StartOffset: 0, EndOffset: len(text), Type: "word"}}
+}
+
+// appendToken appends text[start:end], split every maxTokenLength runes.
+// A maxTokenLength below 1 does not split the token.
+func appendToken(tokens []*analyzeToken, text []rune, start, end int, tokenType string, maxTokenLength int) []*analyzeToken {
+	if maxTokenLength < 1 {
+		maxTokenLength = end - start
+	}
+	for ; start < end; start += maxTokenLength {
+		stop := start + maxTokenLength
+		if stop > end {
+			stop = end
+		}
+		tokens = append(tokens, &analyzeToken{
+			Token:       string(text[start:stop]),
+			StartOffset: start,
+			EndOffset:   stop,
+			Type:        tokenType,
+			Position:    len(tokens),
+		})
+	}
+	return tokens
+}
+
+func mapTokens(fn func(string) string) analyzeTokenFilter {
+	return func(tokens []*analyzeToken) []*analyzeToken {
+		for _, token := range tokens {
+			token.Token = fn(token.Token)
+		}
+		return tokens
+	}
+}
+
+// stopFilter removes stopwords, keeping the position gaps they leave.
+func stopFilter(params map[string]interface{}) analyzeTokenFilter {
+	stopwords := analysisStringsParam(params, "stopwords")
+	if len(stopwords) == 0 || (len(stopwords) == 1 && stopwords[0] == "_english_") {
+		stopwords = englishStopwords
+	} else if len(stopwords) == 1 && stopwords[0] == "_none_" {
+		stopwords = nil
+	}
+	ignoreCase, _ := params["ignore_case"].(bool)
+	set := make(map[string]bool, len(stopwords))
+	for _, word := range stopwords {
+		if ignoreCase {
+			word = strings.ToLower(word)
+		}
+		set[word] = true
+	}
+	return func(tokens []*analyzeToken) []*analyzeToken {
+		kept := make([]*analyzeToken, 0, len(tokens))
+		for _, token := range tokens {
+			word := token.Token
+			if ignoreCase {
+				word = strings.ToLower(word)
+			}
+			if !set[word] {
+				kept = append(kept, token)
+			}
+		}
+		return kept
+	}
+}
+
+// edgeNgramFilter replaces each token with its prefixes, at the position
+// and offsets of the token.
+func edgeNgramFilter(params map[string]interface{}) analyzeTokenFilter {
+	minGram := analysisIntParam(params, "min_gram", 1)
+	maxGram := analysisIntParam(params, "max_gram", 2)
+	preserveOriginal, _ := params["preserve_original"].(bool)
+	return func(tokens []*analyzeToken) []*analyzeToken {
+		grams := make([]*analyzeToken, 0, len(tokens))
+		for _, token := range tokens {
+			runes := []rune(token.Token)
+			for n := minGram; n <= maxGram && n <= len(runes); n++ {
+				gram := *token
+				gram.Token = string(runes[:n])
+				grams = append(grams, &gram)
+			}
+			if preserveOriginal && (len(runes) < minGram || len(runes) > maxGram) {
+				grams = append(grams, token)
+			}
+		}
+		return grams
+	}
+}
+
+// synonymFilter applies Solr format rules, matching the longest sequence
+// of tokens first. Synonyms are added at the position of the first
+// matched token with the type SYNONYM.
+func synonymFilter(params map[string]interface{}, prefix *analyzeChain) (analyzeTokenFilter, error) {
+	if _, ok := params["synonyms_path"]; ok {
+		return nil, fmt.Errorf("synonyms_path is not supported locally")
+	}
+	expand := true
+	if v, ok := params["expand"].(bool); ok {
+		expand = v
+	}
+	analyzePhrase := func(phrase string) []string {
+		tokens := prefix.run(strings.TrimSpace(phrase))
+		words := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			words = append(words, token.Token)
+		}
+		return words
+	}
+	rules := make(map[string][][]string)
+	addRule := func(from []string, to [][]string) {
+		key := strings.Join(from, "\x00")
+		for _, words := range to {
+			duplicate := false
+			for _, existing := range rules[key] {
+				if strings.Join(existing, "\x00") == strings.Join(words, "\x00") {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				rules[key] = append(rules[key], words)
+			}
+		}
+	}
+	maxLength := 0
+	for _, rule := range analysisStringsParam(params, "synonyms") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		var from, to [][]string
+		sides := strings.SplitN(rule, "=>", 2)
+		for _, phrase := range strings.Split(sides[0], ",") {
+			if words := analyzePhrase(phrase); len(words) > 0 {
+				from = append(from, words)
+			}
+		}
+		if len(sides) == 2 {
+			for _, phrase := range strings.Split(sides[1], ",") {
+				if words := analyzePhrase(phrase); len(words) > 0 {
+					to = append(to, words)
+				}
+			}
+		} else if expand {
+			to = from
+		} else if len(from) > 0 {
+			to = from[:1]
+		}
+		if len(from) == 0 || len(to) == 0 {
+			return nil, fmt.Errorf("invalid synonym rule %q", rule)
+		}
+		for _, words := range from {
+			addRule(words, to)
+			if len(words) > maxLength {
+				maxLength = len(words)
+			}
+		}
+	}
+	return func(tokens []*analyzeToken) []*analyzeToken {
+		result := make([]*analyzeToken, 0, len(tokens))
+		for i := 0; i < len(tokens); {
+			matched := 0
+			var replacements [][]string
+			for n := maxLength; n > 0; n-- {
+				if i+n > len(tokens) {
+					continue
+				}
+				words := make([]string, 0, n)
+				for _, token := range tokens[i : i+n] {
+					words = append(words, token.Token)
+				}
+				if r, ok := rules[strings.Join(words, "\x00")]; ok {
+					matched, replacements = n, r
+					break
+				}
+			}
+			if matched == 0 {
+				result = append(result, tokens[i])
+				i++
+				continue
+			}
+			first, last := tokens[i], tokens[i+matched-1]
+			original := make([]string, 0, matched)
+			for _, token := range tokens[i : i+matched] {
+				original = append(original, token.Token)
+			}
+			for _, words := range replacements {
+				if strings.Join(words, "\x00") == strings.Join(original, "\x00") {
+					result = append(result, tokens[i:i+matched]...)
+					continue
+				}
+				for j, word := range words {
+					result = append(result, &analyzeToken{
+						Token:       word,
+						StartOffset: first.StartOffset,
+						EndOffset:   last.EndOffset,
+						Type:        "SYNONYM",
+						Position:    first.Position + j,
+					})
+				}
+			}
+			i += matched
+		}
+		sort.SliceStable(result, func(a, b int) bool {
+			return result[a].Position < result[b].Position
+		})
+		return result
+	}, nil
+}
+
+func analysisIntParam(params map[string]interface{}, name string, defaultValue int) int {
+	if v, ok := intParam(params[name]); ok {
+		return int(v)
+	}
+	return defaultValue
+}
+
+func analysisStringsParam(params map[string]interface{}, name string) []string {
+	switch v := params[name].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	}
+	return nil
+}
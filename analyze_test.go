@@ -0,0 +1,125 @@
+package esbuilder
+
+import (
+	"strings"
+	"testing"
+)
+
+// tokenStrings joins the tokens of an analysis for comparison.
+func tokenStrings(tokens []*analyzeToken) string {
+	texts := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		texts = append(texts, token.Token)
+	}
+	return strings.Join(texts, " ")
+}
+
+func TestAnalyze(t *testing.T) {
+	a := NewAnalysis().
+		Analyzer("english_stop", NewAnalyzer("standard").Param("stopwords", "_english_")).
+		Analyzer("short", NewCustomAnalyzer("short").Filter("lowercase")).
+		Analyzer("autocomplete", NewCustomAnalyzer("standard").Filter("lowercase", "prefixes")).
+		Analyzer("synonyms", NewCustomAnalyzer("whitespace").Filter("lowercase", "tv")).
+		Analyzer("phone", NewCustomAnalyzer("whitespace").CharFilter("ph", "html_strip", "digits")).
+		Tokenizer("short", NewTokenizer("whitespace").Param("max_token_length", 3)).
+		TokenFilter("prefixes", NewEdgeNgramFilter(1, 3)).
+		TokenFilter("tv", NewSynonymFilter("tv, television")).
+		CharFilter("ph", NewMappingCharFilter("ph => f")).
+		CharFilter("digits", NewPatternReplaceCharFilter(`\d+`, "#"))
+	tests := []struct {
+		analyzer string
+		text     string
+		want     string
+	}{
+		{"standard", "The QUICK brown-fox's 3.14", "the quick brown fox's 3.14"},
+		{"standard", "日本", "日 本"},
+		{"english_stop", "The quick fox", "quick fox"},
+		{"whitespace", "The quick-fox", "The quick-fox"},
+		{"keyword", "The quick fox", "The quick fox"},
+		{"short", "ABCDEFG hi", "abc def g hi"},
+		{"autocomplete", "Fox", "f fo fox"},
+		{"synonyms", "TV", "tv television"},
+		{"phone", "<b>phone</b> 555", "fone #"},
+	}
+	for _, tt := range tests {
+		tokens, err := a.Analyze(tt.analyzer, tt.text)
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.analyzer, tt.text, err)
+			continue
+		}
+		if got := tokenStrings(tokens); got != tt.want {
+			t.Errorf("%s %q = %q, want %q", tt.analyzer, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	a := NewAnalysis().
+		Analyzer("zero", NewCustomAnalyzer("zero")).
+		Analyzer("negative", NewAnalyzer("standard").Param("max_token_length", -1)).
+		Analyzer("ngram", NewCustomAnalyzer("ngram")).
+		Analyzer("unknown_filter", NewCustomAnalyzer("standard").Filter("porter_stem")).
+		Analyzer("bad_pattern", NewCustomAnalyzer("standard").CharFilter("bad")).
+		Tokenizer("zero", NewTokenizer("standard").Param("max_token_length", 0)).
+		CharFilter("bad", NewPatternReplaceCharFilter("(", ""))
+	tests := []struct {
+		analyzer string
+		err      string
+	}{
+		{"missing", "unknown analyzer missing"},
+		{"zero", "tokenizer zero: standard: max_token_length must be at least 1"},
+		{"negative", "analyzer negative: standard: max_token_length must be at least 1"},
+		{"ngram", "ngram tokenizer is not supported locally"},
+		{"unknown_filter", "porter_stem token filter is not supported locally"},
+		{"bad_pattern", "pattern_replace"},
+		{"fingerprint", "fingerprint analyzer is not supported locally"},
+	}
+	for _, tt := range tests {
+		_, err := a.Analyze(tt.analyzer, "text")
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err = %v, want %q", tt.analyzer, err, tt.err)
+		}
+	}
+	if _, err := a.Build(); err == nil || !strings.Contains(err.Error(), "max_token_length") {
+		t.Errorf("Build err = %v", err)
+	}
+}
+
+func TestAppendToken(t *testing.T) {
+	text := []rune("abcdefg")
+	tests := []struct {
+		maxTokenLength int
+		want           string
+	}{
+		{255, "abcdefg"},
+		{3, "abc def g"},
+		{1, "a b c d e f g"},
+		{0, "abcdefg"},
+		{-2, "abcdefg"},
+	}
+	for _, tt := range tests {
+		if got := tokenStrings(appendToken(nil, text, 0, len(text), "word", tt.maxTokenLength)); got != tt.want {
+			t.Errorf("max_token_length %d = %q, want %q", tt.maxTokenLength, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	a := NewAnalysis().Normalizer("folded", NewCustomNormalizer().Filter("uppercase"))
+	tests := []struct {
+		normalizer string
+		text       string
+		want       string
+		err        bool
+	}{
+		{"lowercase", "ABC Def", "abc def", false},
+		{"folded", "abc", "ABC", false},
+		{"missing", "abc", "", true},
+	}
+	for _, tt := range tests {
+		got, err := a.Normalize(tt.normalizer, tt.text)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("%s %q = %q, %v", tt.normalizer, tt.text, got, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-boosting-query.html
+type boostingQuery struct {
+	positiveItem  query
+	negativeItem  query
+	negativeBoost *float64
+	boost         *float64
+}
+
+// NewBoostingQuery creates and initializes a new boostingQuery.
+func NewBoostingQuery() *boostingQuery {
+	return &boostingQuery{}
+}
+
+// Positive sets the query that documents must match.
+func (q *boostingQuery) Positive(positive query) *boostingQuery {
+	q.positiveItem = positive
+	return q
+}
+
+// Negative sets the query used to decrease the relevance score of
+// matching documents.
+func (q *boostingQuery) Negative(negative query) *boostingQuery {
+	q.negativeItem = negative
+	return q
+}
+
+// NegativeBoost is the number in [0, 1] used to decrease the relevance
+// score of documents matching the negative query.
+func (q *boostingQuery) NegativeBoost(negativeBoost float64) *boostingQuery {
+	q.negativeBoost = &negativeBoost
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *boostingQuery) Boost(boost float64) *boostingQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the boosting query.
+func (q *boostingQuery) Build() (interface{}, error) {
+	if q.positiveItem == nil || q.negativeItem == nil || q.negativeBoost == nil {
+		return nil, fmt.Errorf("boosting: positive, negative and negative_boost must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["boosting"] = params
+
+	positive, err := q.positiveItem.Build()
+	if err != nil {
+		return nil, err
+	}
+	params["positive"] = positive
+
+	negative, err := q.negativeItem.Build()
+	if err != nil {
+		return nil, err
+	}
+	params["negative"] = negative
+
+	params["negative_boost"] = *q.negativeBoost
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
@@ -0,0 +1,358 @@
+package esbuilder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-bulk.html
+type bulkAction struct {
+	opType          string
+	index           string
+	id              string
+	routing         string
+	pipeline        string
+	version         *int64
+	versionType     string
+	ifSeqNo         *int64
+	ifPrimaryTerm   *int64
+	retryOnConflict *int
+	doc             interface{}
+	upsert          interface{}
+	script          *script
+	docAsUpsert     *bool
+	scriptedUpsert  *bool
+	onSuccess       func(ctx context.Context, action *bulkAction, item *bulkResponseItem)
+	onFailure       func(ctx context.Context, action *bulkAction, item *bulkResponseItem, err error)
+}
+
+// NewBulkIndexAction indexes doc, replacing an existing document.
+func NewBulkIndexAction(doc interface{}) *bulkAction {
+	return &bulkAction{opType: "index", doc: doc}
+}
+
+// NewBulkCreateAction indexes doc if no document with the same _id exists.
+func NewBulkCreateAction(doc interface{}) *bulkAction {
+	return &bulkAction{opType: "create", doc: doc}
+}
+
+// NewBulkUpdateAction partially updates a document, see Doc, Upsert and Script.
+func NewBulkUpdateAction(id string) *bulkAction {
+	return &bulkAction{opType: "update", id: id}
+}
+
+// NewBulkDeleteAction deletes the document with the given _id.
+func NewBulkDeleteAction(id string) *bulkAction {
+	return &bulkAction{opType: "delete", id: id}
+}
+
+// Index sets the target index. It may be left empty when the bulk request
+// has a default index.
+func (a *bulkAction) Index(index string) *bulkAction {
+	a.index = index
+	return a
+}
+
+// Id sets the document _id.
+func (a *bulkAction) Id(id string) *bulkAction {
+	a.id = id
+	return a
+}
+
+// Routing sets the routing value of the document.
+func (a *bulkAction) Routing(routing string) *bulkAction {
+	a.routing = routing
+	return a
+}
+
+// Pipeline sets the ingest pipeline used to preprocess the document.
+func (a *bulkAction) Pipeline(pipeline string) *bulkAction {
+	a.pipeline = pipeline
+	return a
+}
+
+// Version sets the expected or external version of the document.
+func (a *bulkAction) Version(version int64) *bulkAction {
+	a.version = &version
+	return a
+}
+
+// VersionType is internal, external or external_gte.
+func (a *bulkAction) VersionType(versionType string) *bulkAction {
+	a.versionType = versionType
+	return a
+}
+
+// IfSeqNo only performs the operation if the document has this sequence number.
+func (a *bulkAction) IfSeqNo(seqNo int64) *bulkAction {
+	a.ifSeqNo = &seqNo
+	return a
+}
+
+// IfPrimaryTerm only performs the operation if the document has this primary term.
+func (a *bulkAction) IfPrimaryTerm(primaryTerm int64) *bulkAction {
+	a.ifPrimaryTerm = &primaryTerm
+	return a
+}
+
+// RetryOnConflict is the number of retries of an update on version conflicts.
+func (a *bulkAction) RetryOnConflict(retries int) *bulkAction {
+	a.retryOnConflict = &retries
+	return a
+}
+
+// Doc sets the partial document of an update.
+func (a *bulkAction) Doc(doc interface{}) *bulkAction {
+	a.doc = doc
+	return a
+}
+
+// Upsert sets the document indexed by an update if it does not exist.
+func (a *bulkAction) Upsert(upsert interface{}) *bulkAction {
+	a.upsert = upsert
+	return a
+}
+
+// Script sets the script of an update.
+func (a *bulkAction) Script(script *script) *bulkAction {
+	a.script = script
+	return a
+}
+
+// DocAsUpsert uses the partial document as upsert document.
+func (a *bulkAction) DocAsUpsert(docAsUpsert bool) *bulkAction {
+	a.docAsUpsert = &docAsUpsert
+	return a
+}
+
+// ScriptedUpsert runs the script whether or not the document exists.
+func (a *bulkAction) ScriptedUpsert(scriptedUpsert bool) *bulkAction {
+	a.scriptedUpsert = &scriptedUpsert
+	return a
+}
+
+// OnSuccess is called by the bulk indexer when the action succeeded.
+func (a *bulkAction) OnSuccess(fn func(ctx context.Context, action *bulkAction, item *bulkResponseItem)) *bulkAction {
+	a.onSuccess = fn
+	return a
+}
+
+// OnFailure is called by the bulk indexer when the action failed. item is
+// nil if the whole request failed.
+func (a *bulkAction) OnFailure(fn func(ctx context.Context, action *bulkAction, item *bulkResponseItem, err error)) *bulkAction {
+	a.onFailure = fn
+	return a
+}
+
+// Build returns the action line followed by the source line, if any.
+func (a *bulkAction) Build() (interface{}, error) {
+	meta := make(map[string]interface{})
+	if a.index != "" {
+		meta["_index"] = a.index
+	}
+	if a.id != "" {
+		meta["_id"] = a.id
+	}
+	if a.routing != "" {
+		meta["routing"] = a.routing
+	}
+	if a.pipeline != "" {
+		meta["pipeline"] = a.pipeline
+	}
+	if a.version != nil {
+		meta["version"] = *a.version
+	}
+	if a.versionType != "" {
+		meta["version_type"] = a.versionType
+	}
+	if a.ifSeqNo != nil {
+		meta["if_seq_no"] = *a.ifSeqNo
+	}
+	if a.ifPrimaryTerm != nil {
+		meta["if_primary_term"] = *a.ifPrimaryTerm
+	}
+	if a.retryOnConflict != nil {
+		if a.opType != "update" {
+			return nil, fmt.Errorf("bulk %s: retry_on_conflict is only supported by update", a.opType)
+		}
+		meta["retry_on_conflict"] = *a.retryOnConflict
+	}
+	lines := []interface{}{
+		map[string]interface{}{a.opType: meta},
+	}
+
+	switch a.opType {
+	case "index", "create":
+		if a.doc == nil {
+			return nil, fmt.Errorf("bulk %s: document can not be empty", a.opType)
+		}
+		lines = append(lines, a.doc)
+	case "update":
+		if a.id == "" {
+			return nil, fmt.Errorf("bulk update: _id must be set")
+		}
+		if (a.doc == nil) == (a.script == nil) {
+			return nil, fmt.Errorf("bulk update: exactly one of doc and script must be set")
+		}
+		body := make(map[string]interface{})
+		if a.doc != nil {
+			body["doc"] = a.doc
+		}
+		if a.script != nil {
+			src, err := a.script.Build()
+			if err != nil {
+				return nil, err
+			}
+			body["script"] = src
+		}
+		if a.upsert != nil {
+			body["upsert"] = a.upsert
+		}
+		if a.docAsUpsert != nil {
+			body["doc_as_upsert"] = *a.docAsUpsert
+		}
+		if a.scriptedUpsert != nil {
+			body["scripted_upsert"] = *a.scriptedUpsert
+		}
+		lines = append(lines, body)
+	case "delete":
+		if a.id == "" {
+			return nil, fmt.Errorf("bulk delete: _id must be set")
+		}
+	}
+	return lines, nil
+}
+
+// encode returns the NDJSON lines of the action.
+func (a *bulkAction) encode() ([]byte, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	src, err := a.Build()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, line := range src.([]interface{}) {
+		data, err := json.Marshal(line)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+type bulk struct {
+	actions []*bulkAction
+}
+
+// NewBulk creates and initializes a new bulk request.
+func NewBulk(actions ...*bulkAction) *bulk {
+	return &bulk{actions: append(make([]*bulkAction, 0), actions...)}
+}
+
+// Add adds actions to the request.
+func (b *bulk) Add(actions ...*bulkAction) *bulk {
+	b.actions = append(b.actions, actions...)
+	return b
+}
+
+// BuildNdjson returns the newline delimited body of the bulk request.
+func (b *bulk) BuildNdjson() (string, error) {
+	var buf bytes.Buffer
+	for _, action := range b.actions {
+		data, err := action.encode()
+		if err != nil {
+			return "", err
+		}
+		buf.Write(data)
+	}
+	return buf.String(), nil
+}
+
+// Do sends the bulk request. index is the default index of actions
+// without one and may be empty.
+func (b *bulk) Do(ctx context.Context, t Transport, index string) (*bulkResponse, error) {
+	if len(b.actions) == 0 {
+		return nil, fmt.Errorf("bulk: at least one action must be added")
+	}
+	body, err := b.BuildNdjson()
+	if err != nil {
+		return nil, err
+	}
+	return performBulk(ctx, t, index, []byte(body))
+}
+
+func performBulk(ctx context.Context, t Transport, index string, body []byte) (*bulkResponse, error) {
+	path := "_bulk"
+	if index != "" {
+		path = url.PathEscape(index) + "/_bulk"
+	}
+	status, data, err := t.Perform(ctx, "POST", path, body)
+	if err != nil {
+		return nil, err
+	}
+	if status < 200 || status > 299 {
+		return nil, newResponseError(status, data)
+	}
+	return ParseBulkResponse(data)
+}
+
+type bulkResponse struct {
+	Took   int64                          `json:"took"`
+	Errors bool                           `json:"errors"`
+	Items  []map[string]*bulkResponseItem `json:"items"`
+}
+
+type bulkResponseItem struct {
+	Index       string      `json:"_index"`
+	Id          string      `json:"_id"`
+	Version     int64       `json:"_version,omitempty"`
+	Result      string      `json:"result,omitempty"`
+	Status      int         `json:"status"`
+	SeqNo       int64       `json:"_seq_no,omitempty"`
+	PrimaryTerm int64       `json:"_primary_term,omitempty"`
+	Error       interface{} `json:"error,omitempty"`
+}
+
+// ParseBulkResponse decodes the body of a bulk response.
+func ParseBulkResponse(data []byte) (*bulkResponse, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	resp := &bulkResponse{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// item returns the single operation result of the i-th item.
+func (r *bulkResponse) item(i int) *bulkResponseItem {
+	if i >= len(r.Items) {
+		return nil
+	}
+	for _, item := range r.Items[i] {
+		return item
+	}
+	return nil
+}
+
+// Err returns the error of a failed item, nil if it succeeded.
+func (item *bulkResponseItem) Err() error {
+	if item.Status >= 200 && item.Status <= 299 && item.Error == nil {
+		return nil
+	}
+	e := &ResponseError{Status: item.Status}
+	switch v := item.Error.(type) {
+	case string:
+		e.Reason = v
+	case map[string]interface{}:
+		e.Type, _ = v["type"].(string)
+		e.Reason, _ = v["reason"].(string)
+	}
+	return e
+}
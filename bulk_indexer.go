@@ -0,0 +1,341 @@
+package esbuilder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// bulkIndexer sends actions to the bulk API from concurrent workers.
+// Each worker flushes its buffer when it holds FlushCount actions or
+// FlushBytes bytes, and every FlushInterval. Items rejected with status
+// 429 are retried with backoff.
+type bulkIndexer struct {
+	transport     Transport
+	index         string
+	workers       int
+	flushBytes    int
+	flushCount    int
+	flushInterval time.Duration
+	maxRetries    int
+	backoff       func(attempt int) time.Duration
+	onError       func(ctx context.Context, err error)
+
+	queue   chan *bulkIndexerItem
+	closing chan struct{}
+	runCtx  context.Context
+	wg      sync.WaitGroup
+	senders sync.WaitGroup
+	started bool
+	closed  bool
+	mu      sync.Mutex
+	stats   bulkIndexerStats
+}
+
+type bulkIndexerItem struct {
+	action *bulkAction
+	body   []byte
+}
+
+// bulkIndexerStats are the counters of a bulkIndexer.
+type bulkIndexerStats struct {
+	NumAdded    uint64
+	NumFlushed  uint64
+	NumFailed   uint64
+	NumIndexed  uint64
+	NumCreated  uint64
+	NumUpdated  uint64
+	NumDeleted  uint64
+	NumRequests uint64
+	NumRetries  uint64
+}
+
+// NewBulkIndexer creates and initializes a new bulkIndexer.
+func NewBulkIndexer(t Transport) *bulkIndexer {
+	return &bulkIndexer{
+		transport:     t,
+		workers:       1,
+		flushBytes:    5 << 20,
+		flushCount:    1000,
+		flushInterval: 30 * time.Second,
+		maxRetries:    3,
+		backoff: func(attempt int) time.Duration {
+			return time.Duration(1<<uint(attempt-1)) * 100 * time.Millisecond
+		},
+	}
+}
+
+// Index sets the default index of actions without one.
+func (bi *bulkIndexer) Index(index string) *bulkIndexer {
+	bi.index = index
+	return bi
+}
+
+// Workers sets the number of concurrent workers. Defaults to 1.
+func (bi *bulkIndexer) Workers(workers int) *bulkIndexer {
+	if workers > 0 {
+		bi.workers = workers
+	}
+	return bi
+}
+
+// FlushBytes flushes a worker buffer once it reaches this size. Defaults to 5MB.
+func (bi *bulkIndexer) FlushBytes(flushBytes int) *bulkIndexer {
+	bi.flushBytes = flushBytes
+	return bi
+}
+
+// FlushCount flushes a worker buffer once it holds this many actions.
+// Defaults to 1000.
+func (bi *bulkIndexer) FlushCount(flushCount int) *bulkIndexer {
+	bi.flushCount = flushCount
+	return bi
+}
+
+// FlushInterval periodically flushes the worker buffers. Defaults to 30s.
+func (bi *bulkIndexer) FlushInterval(flushInterval time.Duration) *bulkIndexer {
+	bi.flushInterval = flushInterval
+	return bi
+}
+
+// MaxRetries is the number of times items rejected with status 429 are
+// retried. Defaults to 3.
+func (bi *bulkIndexer) MaxRetries(maxRetries int) *bulkIndexer {
+	bi.maxRetries = maxRetries
+	return bi
+}
+
+// Backoff returns the delay before the given retry attempt, starting at 1.
+// Defaults to exponential backoff from 100ms.
+func (bi *bulkIndexer) Backoff(backoff func(attempt int) time.Duration) *bulkIndexer {
+	bi.backoff = backoff
+	return bi
+}
+
+// OnError is called when a bulk request fails as a whole.
+func (bi *bulkIndexer) OnError(fn func(ctx context.Context, err error)) *bulkIndexer {
+	bi.onError = fn
+	return bi
+}
+
+// Start starts the workers. Workers stop when Close is called. Once ctx is
+// done, buffered and queued actions fail with the error of ctx and Add
+// returns it.
+func (bi *bulkIndexer) Start(ctx context.Context) error {
+	bi.mu.Lock()
+	defer bi.mu.Unlock()
+	if bi.started {
+		return fmt.Errorf("bulk indexer: already started")
+	}
+	bi.started = true
+	bi.queue = make(chan *bulkIndexerItem, bi.workers)
+	bi.closing = make(chan struct{})
+	bi.runCtx = ctx
+	for i := 0; i < bi.workers; i++ {
+		bi.wg.Add(1)
+		go bi.work(ctx)
+	}
+	return nil
+}
+
+// Add queues an action. It blocks while all workers are busy.
+func (bi *bulkIndexer) Add(ctx context.Context, action *bulkAction) error {
+	body, err := action.encode()
+	if err != nil {
+		return err
+	}
+	// Close waits for registered senders before closing the queue, so the
+	// lock is not held while the send blocks.
+	bi.mu.Lock()
+	if !bi.started || bi.closed {
+		bi.mu.Unlock()
+		return fmt.Errorf("bulk indexer: not running")
+	}
+	if err := bi.runCtx.Err(); err != nil {
+		bi.mu.Unlock()
+		return fmt.Errorf("bulk indexer: stopped: %v", err)
+	}
+	bi.senders.Add(1)
+	bi.mu.Unlock()
+	defer bi.senders.Done()
+	select {
+	case bi.queue <- &bulkIndexerItem{action: action, body: body}:
+		atomic.AddUint64(&bi.stats.NumAdded, 1)
+		return nil
+	case <-bi.closing:
+		return fmt.Errorf("bulk indexer: not running")
+	case <-bi.runCtx.Done():
+		return fmt.Errorf("bulk indexer: stopped: %v", bi.runCtx.Err())
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes the pending actions and waits for the workers to stop.
+func (bi *bulkIndexer) Close(ctx context.Context) error {
+	bi.mu.Lock()
+	if !bi.started || bi.closed {
+		bi.mu.Unlock()
+		return fmt.Errorf("bulk indexer: not running")
+	}
+	bi.closed = true
+	close(bi.closing)
+	bi.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		bi.senders.Wait()
+		close(bi.queue)
+		bi.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns a snapshot of the counters.
+func (bi *bulkIndexer) Stats() bulkIndexerStats {
+	return bulkIndexerStats{
+		NumAdded:    atomic.LoadUint64(&bi.stats.NumAdded),
+		NumFlushed:  atomic.LoadUint64(&bi.stats.NumFlushed),
+		NumFailed:   atomic.LoadUint64(&bi.stats.NumFailed),
+		NumIndexed:  atomic.LoadUint64(&bi.stats.NumIndexed),
+		NumCreated:  atomic.LoadUint64(&bi.stats.NumCreated),
+		NumUpdated:  atomic.LoadUint64(&bi.stats.NumUpdated),
+		NumDeleted:  atomic.LoadUint64(&bi.stats.NumDeleted),
+		NumRequests: atomic.LoadUint64(&bi.stats.NumRequests),
+		NumRetries:  atomic.LoadUint64(&bi.stats.NumRetries),
+	}
+}
+
+func (bi *bulkIndexer) work(ctx context.Context) {
+	defer bi.wg.Done()
+	var buf []*bulkIndexerItem
+	size := 0
+	var tick <-chan time.Time
+	if bi.flushInterval > 0 {
+		ticker := time.NewTicker(bi.flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	flush := func() {
+		if len(buf) > 0 {
+			bi.flush(ctx, buf)
+			buf = nil
+			size = 0
+		}
+	}
+	for {
+		select {
+		case item, ok := <-bi.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if size > 0 && bi.flushBytes > 0 && size+len(item.body) > bi.flushBytes {
+				flush()
+			}
+			buf = append(buf, item)
+			size += len(item.body)
+			if (bi.flushCount > 0 && len(buf) >= bi.flushCount) || (bi.flushBytes > 0 && size >= bi.flushBytes) {
+				flush()
+			}
+		case <-tick:
+			flush()
+		case <-ctx.Done():
+			for _, item := range buf {
+				bi.fail(ctx, item, nil, ctx.Err())
+			}
+			// Fail what is still queued or added until Close closes the queue.
+			for item := range bi.queue {
+				bi.fail(ctx, item, nil, ctx.Err())
+			}
+			return
+		}
+	}
+}
+
+// flush sends items, retrying the ones rejected with status 429.
+func (bi *bulkIndexer) flush(ctx context.Context, items []*bulkIndexerItem) {
+	for attempt := 0; len(items) > 0; attempt++ {
+		if attempt > 0 {
+			atomic.AddUint64(&bi.stats.NumRetries, uint64(len(items)))
+			select {
+			case <-time.After(bi.backoff(attempt)):
+			case <-ctx.Done():
+				for _, item := range items {
+					bi.fail(ctx, item, nil, ctx.Err())
+				}
+				return
+			}
+		}
+		var body bytes.Buffer
+		for _, item := range items {
+			body.Write(item.body)
+		}
+		atomic.AddUint64(&bi.stats.NumRequests, 1)
+		resp, err := performBulk(ctx, bi.transport, bi.index, body.Bytes())
+		if err != nil {
+			if re, ok := err.(*ResponseError); ok && re.Status == 429 && attempt < bi.maxRetries {
+				continue
+			}
+			if bi.onError != nil {
+				bi.onError(ctx, err)
+			}
+			for _, item := range items {
+				bi.fail(ctx, item, nil, err)
+			}
+			return
+		}
+
+		var retry []*bulkIndexerItem
+		for i, item := range items {
+			result := resp.item(i)
+			if result == nil {
+				bi.fail(ctx, item, nil, fmt.Errorf("bulk indexer: missing response item %d", i))
+				continue
+			}
+			if result.Status == 429 && attempt < bi.maxRetries {
+				retry = append(retry, item)
+				continue
+			}
+			if err := result.Err(); err != nil {
+				bi.fail(ctx, item, result, err)
+				continue
+			}
+			bi.succeed(ctx, item, result)
+		}
+		items = retry
+	}
+}
+
+func (bi *bulkIndexer) succeed(ctx context.Context, item *bulkIndexerItem, result *bulkResponseItem) {
+	atomic.AddUint64(&bi.stats.NumFlushed, 1)
+	switch item.action.opType {
+	case "index":
+		atomic.AddUint64(&bi.stats.NumIndexed, 1)
+	case "create":
+		atomic.AddUint64(&bi.stats.NumCreated, 1)
+	case "update":
+		atomic.AddUint64(&bi.stats.NumUpdated, 1)
+	case "delete":
+		atomic.AddUint64(&bi.stats.NumDeleted, 1)
+	}
+	if item.action.onSuccess != nil {
+		item.action.onSuccess(ctx, item.action, result)
+	}
+}
+
+func (bi *bulkIndexer) fail(ctx context.Context, item *bulkIndexerItem, result *bulkResponseItem, err error) {
+	atomic.AddUint64(&bi.stats.NumFailed, 1)
+	if item.action.onFailure != nil {
+		item.action.onFailure(ctx, item.action, result, err)
+	}
+}
@@ -0,0 +1,288 @@
+package esbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBulk answers bulk requests with the item statuses returned by
+// status for each action _id. request counts the bulk requests sent so far.
+type fakeBulk struct {
+	mu       sync.Mutex
+	requests int
+	paths    []string
+	status   func(request int, id string) int
+	httpCode int
+}
+
+func (f *fakeBulk) Perform(ctx context.Context, method, path string, body []byte) (int, []byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	f.paths = append(f.paths, path)
+	if f.httpCode != 0 {
+		return f.httpCode, []byte(`{"error":{"type":"es_rejected_execution_exception","reason":"busy"}}`), nil
+	}
+	items := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		var action map[string]map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &action); err != nil {
+			continue
+		}
+		meta, ok := action["index"]
+		if !ok || len(action) != 1 {
+			continue
+		}
+		id, _ := meta["_id"].(string)
+		status := 201
+		if f.status != nil {
+			status = f.status(f.requests, id)
+		}
+		if status < 0 {
+			// Leave the item out of the response.
+			continue
+		}
+		item := fmt.Sprintf(`{"index":{"_index":"i","_id":%q,"status":%d}}`, id, status)
+		if status >= 300 {
+			item = fmt.Sprintf(`{"index":{"_index":"i","_id":%q,"status":%d,"error":{"type":"error","reason":"status %d"}}}`, id, status, status)
+		}
+		items = append(items, item)
+	}
+	return 200, []byte(`{"took":1,"errors":false,"items":[` + strings.Join(items, ",") + `]}`), nil
+}
+
+// bulkOutcome collects the callbacks of the actions of a test.
+type bulkOutcome struct {
+	mu        sync.Mutex
+	succeeded []string
+	failed    map[string]error
+}
+
+func (o *bulkOutcome) action(id string) *bulkAction {
+	return NewBulkIndexAction(map[string]string{"id": id}).Id(id).
+		OnSuccess(func(ctx context.Context, action *bulkAction, item *bulkResponseItem) {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+			o.succeeded = append(o.succeeded, id)
+		}).
+		OnFailure(func(ctx context.Context, action *bulkAction, item *bulkResponseItem, err error) {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+			o.failed[id] = err
+		})
+}
+
+func TestBulkIndexer(t *testing.T) {
+	tests := []struct {
+		name      string
+		fake      *fakeBulk
+		succeeded int
+		failed    int
+		requests  int
+		retries   uint64
+		err       string
+	}{
+		{
+			name:      "all indexed",
+			fake:      &fakeBulk{},
+			succeeded: 5,
+			requests:  3,
+		},
+		{
+			name: "429 retried",
+			fake: &fakeBulk{status: func(request int, id string) int {
+				if request == 1 && id == "0" {
+					return 429
+				}
+				return 201
+			}},
+			succeeded: 5,
+			requests:  4,
+			retries:   1,
+		},
+		{
+			name:     "429 after max retries",
+			fake:     &fakeBulk{status: func(request int, id string) int { return 429 }},
+			failed:   5,
+			requests: 9,
+			retries:  10,
+			err:      "status 429",
+		},
+		{
+			name: "item error",
+			fake: &fakeBulk{status: func(request int, id string) int {
+				if id == "4" {
+					return 400
+				}
+				return 201
+			}},
+			succeeded: 4,
+			failed:    1,
+			requests:  3,
+			err:       "status 400",
+		},
+		{
+			name: "missing response item",
+			fake: &fakeBulk{status: func(request int, id string) int {
+				if id == "1" {
+					return -1
+				}
+				return 201
+			}},
+			succeeded: 4,
+			failed:    1,
+			requests:  3,
+			err:       "missing response item",
+		},
+		{
+			name:     "request error",
+			fake:     &fakeBulk{httpCode: 500},
+			failed:   5,
+			requests: 3,
+			err:      "busy",
+		},
+	}
+	for _, tt := range tests {
+		outcome := &bulkOutcome{failed: make(map[string]error)}
+		var requestErrors int
+		bi := NewBulkIndexer(tt.fake).Index("i").FlushCount(2).MaxRetries(2).
+			Backoff(func(attempt int) time.Duration { return 0 }).
+			OnError(func(ctx context.Context, err error) { requestErrors++ })
+		if err := bi.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			if err := bi.Add(context.Background(), outcome.action(strconv.Itoa(i))); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+		if err := bi.Close(context.Background()); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		stats := bi.Stats()
+		if len(outcome.succeeded) != tt.succeeded || len(outcome.failed) != tt.failed {
+			t.Errorf("%s: %d succeeded, %d failed, want %d and %d", tt.name, len(outcome.succeeded), len(outcome.failed), tt.succeeded, tt.failed)
+		}
+		if stats.NumAdded != 5 || stats.NumIndexed != uint64(tt.succeeded) || stats.NumFailed != uint64(tt.failed) {
+			t.Errorf("%s: stats %+v", tt.name, stats)
+		}
+		if tt.fake.requests != tt.requests || stats.NumRequests != uint64(tt.requests) {
+			t.Errorf("%s: %d requests, want %d", tt.name, tt.fake.requests, tt.requests)
+		}
+		if stats.NumRetries != tt.retries {
+			t.Errorf("%s: %d retries, want %d", tt.name, stats.NumRetries, tt.retries)
+		}
+		for id, err := range outcome.failed {
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: document %s failed with %v, want %q", tt.name, id, err, tt.err)
+			}
+		}
+		if tt.fake.httpCode != 0 && requestErrors != tt.requests {
+			t.Errorf("%s: OnError called %d times", tt.name, requestErrors)
+		}
+		if tt.fake.paths[0] != "i/_bulk" {
+			t.Errorf("%s: path %s", tt.name, tt.fake.paths[0])
+		}
+	}
+}
+
+func TestBulkIndexerNotRunning(t *testing.T) {
+	bi := NewBulkIndexer(&fakeBulk{})
+	if err := bi.Add(context.Background(), NewBulkIndexAction(map[string]int{})); err == nil {
+		t.Error("Add before Start succeeded")
+	}
+	if err := bi.Close(context.Background()); err == nil {
+		t.Error("Close before Start succeeded")
+	}
+	if err := bi.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := bi.Start(context.Background()); err == nil {
+		t.Error("second Start succeeded")
+	}
+	if err := bi.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := bi.Add(context.Background(), NewBulkIndexAction(map[string]int{})); err == nil {
+		t.Error("Add after Close succeeded")
+	}
+}
+
+// blockingTransport holds every request until release is closed.
+type blockingTransport struct {
+	release chan struct{}
+}
+
+func (b *blockingTransport) Perform(ctx context.Context, method, path string, body []byte) (int, []byte, error) {
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+	}
+	return 0, nil, context.Canceled
+}
+
+func TestBulkIndexerCancel(t *testing.T) {
+	transport := &blockingTransport{release: make(chan struct{})}
+	defer close(transport.release)
+	outcome := &bulkOutcome{failed: make(map[string]error)}
+	ctx, cancel := context.WithCancel(context.Background())
+	bi := NewBulkIndexer(transport).FlushCount(1)
+	if err := bi.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// The first action blocks the worker in the transport, the second
+	// waits in the queue.
+	for i := 0; i < 2; i++ {
+		if err := bi.Add(context.Background(), outcome.action(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cancel()
+	if err := bi.Add(context.Background(), outcome.action("late")); err == nil {
+		t.Error("Add after cancellation succeeded")
+	}
+	if err := bi.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.failed) != 2 || len(outcome.succeeded) != 0 {
+		t.Errorf("%d failed, %d succeeded, want 2 failed", len(outcome.failed), len(outcome.succeeded))
+	}
+}
+
+func TestBulkIndexerCloseDeadline(t *testing.T) {
+	transport := &blockingTransport{release: make(chan struct{})}
+	bi := NewBulkIndexer(transport).FlushCount(1)
+	if err := bi.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// One action blocks the worker, one fills the queue and the third Add
+	// blocks on the full queue.
+	added := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			added <- bi.Add(context.Background(), NewBulkIndexAction(map[string]int{"n": 1}))
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bi.Close(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Close = %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(transport.release)
+	rejected := 0
+	for i := 0; i < 3; i++ {
+		if err := <-added; err != nil {
+			rejected++
+		}
+	}
+	if rejected != 1 {
+		t.Errorf("%d Add calls rejected by Close, want 1", rejected)
+	}
+}
@@ -0,0 +1,271 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// byQuery holds the parameters shared by update_by_query and
+// delete_by_query requests.
+type byQuery struct {
+	index     []string
+	queryItem query
+	maxDocs   *int64
+	conflicts string
+	params    url.Values
+}
+
+func newByQuery(index []string) byQuery {
+	return byQuery{
+		index:  append(make([]string, 0), index...),
+		params: make(url.Values),
+	}
+}
+
+func (b *byQuery) build(endpoint string) (map[string]interface{}, error) {
+	if len(b.index) == 0 {
+		return nil, fmt.Errorf("%s: index must be set", endpoint)
+	}
+	if b.conflicts != "" && b.conflicts != "abort" && b.conflicts != "proceed" {
+		return nil, fmt.Errorf("%s: conflicts must be abort or proceed", endpoint)
+	}
+	source := make(map[string]interface{})
+	if b.queryItem != nil {
+		src, err := b.queryItem.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["query"] = src
+	}
+	if b.maxDocs != nil {
+		source["max_docs"] = *b.maxDocs
+	}
+	if b.conflicts != "" {
+		source["conflicts"] = b.conflicts
+	}
+	return source, nil
+}
+
+func (b *byQuery) path(endpoint string) string {
+	indices := make([]string, len(b.index))
+	for i, index := range b.index {
+		indices[i] = url.PathEscape(index)
+	}
+	path := strings.Join(indices, ",") + "/" + endpoint
+	if len(b.params) > 0 {
+		path += "?" + b.params.Encode()
+	}
+	return path
+}
+
+func (b *byQuery) do(ctx context.Context, t Transport, endpoint string, src interface{}) (*byQueryResponse, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	data, err := perform(ctx, t, "POST", b.path(endpoint), src)
+	if err != nil {
+		return nil, err
+	}
+	resp := &byQueryResponse{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// byQueryResponse is the response of update_by_query, delete_by_query and
+// reindex. Only Task is set when wait_for_completion is false.
+type byQueryResponse struct {
+	Task             string        `json:"task,omitempty"`
+	Took             int64         `json:"took"`
+	TimedOut         bool          `json:"timed_out"`
+	Total            int64         `json:"total"`
+	Updated          int64         `json:"updated"`
+	Created          int64         `json:"created"`
+	Deleted          int64         `json:"deleted"`
+	Batches          int64         `json:"batches"`
+	VersionConflicts int64         `json:"version_conflicts"`
+	Noops            int64         `json:"noops"`
+	Failures         []interface{} `json:"failures,omitempty"`
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-update-by-query.html
+type updateByQuery struct {
+	byQuery
+	script *script
+}
+
+// NewUpdateByQuery creates and initializes a new updateByQuery on index.
+func NewUpdateByQuery(index ...string) *updateByQuery {
+	return &updateByQuery{byQuery: newByQuery(index)}
+}
+
+// Query selects the documents to update. All documents are updated if unset.
+func (u *updateByQuery) Query(query query) *updateByQuery {
+	u.queryItem = query
+	return u
+}
+
+// Script sets the script modifying each document.
+func (u *updateByQuery) Script(script *script) *updateByQuery {
+	u.script = script
+	return u
+}
+
+// MaxDocs is the maximum number of documents to process.
+func (u *updateByQuery) MaxDocs(maxDocs int64) *updateByQuery {
+	u.maxDocs = &maxDocs
+	return u
+}
+
+// Conflicts is abort (default) or proceed.
+func (u *updateByQuery) Conflicts(conflicts string) *updateByQuery {
+	u.conflicts = conflicts
+	return u
+}
+
+// Pipeline sets the ingest pipeline documents are sent through.
+func (u *updateByQuery) Pipeline(pipeline string) *updateByQuery {
+	u.params.Set("pipeline", pipeline)
+	return u
+}
+
+// Slices is the number of slices the task is divided into, 0 for auto.
+func (u *updateByQuery) Slices(slices int) *updateByQuery {
+	u.params.Set("slices", formatSlices(slices))
+	return u
+}
+
+// RequestsPerSecond throttles the request, -1 disables throttling.
+func (u *updateByQuery) RequestsPerSecond(rps float64) *updateByQuery {
+	u.params.Set("requests_per_second", strconv.FormatFloat(rps, 'f', -1, 64))
+	return u
+}
+
+// Refresh refreshes the affected shards once the request completes.
+func (u *updateByQuery) Refresh(refresh bool) *updateByQuery {
+	u.params.Set("refresh", strconv.FormatBool(refresh))
+	return u
+}
+
+// WaitForCompletion returns a task id instead of waiting when set to false.
+func (u *updateByQuery) WaitForCompletion(wait bool) *updateByQuery {
+	u.params.Set("wait_for_completion", strconv.FormatBool(wait))
+	return u
+}
+
+// Build returns the body of the update_by_query request.
+func (u *updateByQuery) Build() (interface{}, error) {
+	source, err := u.build("_update_by_query")
+	if err != nil {
+		return nil, err
+	}
+	if u.script != nil {
+		src, err := u.script.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["script"] = src
+	}
+	return source, nil
+}
+
+// Path returns the path and URL parameters of the request.
+func (u *updateByQuery) Path() string {
+	return u.path("_update_by_query")
+}
+
+// Do sends the update_by_query request.
+func (u *updateByQuery) Do(ctx context.Context, t Transport) (*byQueryResponse, error) {
+	src, err := u.Build()
+	if err != nil {
+		return nil, err
+	}
+	return u.do(ctx, t, "_update_by_query", src)
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-delete-by-query.html
+type deleteByQuery struct {
+	byQuery
+}
+
+// NewDeleteByQuery creates and initializes a new deleteByQuery on index.
+func NewDeleteByQuery(index ...string) *deleteByQuery {
+	return &deleteByQuery{byQuery: newByQuery(index)}
+}
+
+// Query selects the documents to delete.
+func (d *deleteByQuery) Query(query query) *deleteByQuery {
+	d.queryItem = query
+	return d
+}
+
+// MaxDocs is the maximum number of documents to process.
+func (d *deleteByQuery) MaxDocs(maxDocs int64) *deleteByQuery {
+	d.maxDocs = &maxDocs
+	return d
+}
+
+// Conflicts is abort (default) or proceed.
+func (d *deleteByQuery) Conflicts(conflicts string) *deleteByQuery {
+	d.conflicts = conflicts
+	return d
+}
+
+// Slices is the number of slices the task is divided into, 0 for auto.
+func (d *deleteByQuery) Slices(slices int) *deleteByQuery {
+	d.params.Set("slices", formatSlices(slices))
+	return d
+}
+
+// RequestsPerSecond throttles the request, -1 disables throttling.
+func (d *deleteByQuery) RequestsPerSecond(rps float64) *deleteByQuery {
+	d.params.Set("requests_per_second", strconv.FormatFloat(rps, 'f', -1, 64))
+	return d
+}
+
+// Refresh refreshes the affected shards once the request completes.
+func (d *deleteByQuery) Refresh(refresh bool) *deleteByQuery {
+	d.params.Set("refresh", strconv.FormatBool(refresh))
+	return d
+}
+
+// WaitForCompletion returns a task id instead of waiting when set to false.
+func (d *deleteByQuery) WaitForCompletion(wait bool) *deleteByQuery {
+	d.params.Set("wait_for_completion", strconv.FormatBool(wait))
+	return d
+}
+
+// Build returns the body of the delete_by_query request.
+func (d *deleteByQuery) Build() (interface{}, error) {
+	if d.queryItem == nil {
+		return nil, fmt.Errorf("_delete_by_query: query must be set")
+	}
+	return d.build("_delete_by_query")
+}
+
+// Path returns the path and URL parameters of the request.
+func (d *deleteByQuery) Path() string {
+	return d.path("_delete_by_query")
+}
+
+// Do sends the delete_by_query request.
+func (d *deleteByQuery) Do(ctx context.Context, t Transport) (*byQueryResponse, error) {
+	src, err := d.Build()
+	if err != nil {
+		return nil, err
+	}
+	return d.do(ctx, t, "_delete_by_query", src)
+}
+
+func formatSlices(slices int) string {
+	if slices <= 0 {
+		return "auto"
+	}
+	return strconv.Itoa(slices)
+}
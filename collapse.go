@@ -0,0 +1,95 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/collapse-search-results.html
+type collapse struct {
+	field                      string
+	innerHits                  []*innerHits
+	maxConcurrentGroupSearches *int
+}
+
+// NewCollapse creates and initializes a new collapse on field.
+func NewCollapse(field string) *collapse {
+	return &collapse{
+		field:     field,
+		innerHits: make([]*innerHits, 0),
+	}
+}
+
+// InnerHits adds named inner_hits to expand the top hits of each group.
+func (c *collapse) InnerHits(innerHits ...*innerHits) *collapse {
+	c.innerHits = append(c.innerHits, innerHits...)
+	return c
+}
+
+// MaxConcurrentGroupSearches is the number of concurrent requests allowed
+// to retrieve the inner_hits per group.
+func (c *collapse) MaxConcurrentGroupSearches(max int) *collapse {
+	c.maxConcurrentGroupSearches = &max
+	return c
+}
+
+// Build returns the map for the collapse section.
+func (c *collapse) Build() (interface{}, error) {
+	if c.field == "" {
+		return nil, fmt.Errorf("collapse: field must be set")
+	}
+	source := map[string]interface{}{
+		"field": c.field,
+	}
+	if len(c.innerHits) == 1 {
+		src, err := c.innerHits[0].Build()
+		if err != nil {
+			return nil, err
+		}
+		source["inner_hits"] = src
+	} else if len(c.innerHits) > 1 {
+		names := make(map[string]bool)
+		var clauses []interface{}
+		for _, h := range c.innerHits {
+			if h.name == "" || names[h.name] {
+				return nil, fmt.Errorf("collapse: multiple inner_hits need distinct names")
+			}
+			names[h.name] = true
+			src, err := h.Build()
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, src)
+		}
+		source["inner_hits"] = clauses
+	}
+	if c.maxConcurrentGroupSearches != nil {
+		source["max_concurrent_group_searches"] = *c.maxConcurrentGroupSearches
+	}
+	return source, nil
+}
+
+// validate checks that collapse is not combined with incompatible
+// features of dsl.
+func (c *collapse) validate(dsl *dsl) error {
+	if dsl.Params.Get("scroll") != "" {
+		return fmt.Errorf("collapse: can not be used in conjunction with scroll")
+	}
+	if len(dsl.Rescore) > 0 {
+		return fmt.Errorf("collapse: can not be used in conjunction with rescore")
+	}
+	if len(dsl.SearchAfter) > 0 {
+		if len(dsl.OrderItems) == 0 {
+			return fmt.Errorf("collapse: search_after requires a sort on %s", c.field)
+		}
+		src, err := dsl.OrderItems[0].Build()
+		if err != nil {
+			return err
+		}
+		if m, ok := src.(map[string]interface{}); !ok || len(m) != 1 || m[c.field] == nil {
+			return fmt.Errorf("collapse: search_after requires the primary sort to be on %s", c.field)
+		}
+		if len(dsl.OrderItems) > 1 {
+			return fmt.Errorf("collapse: search_after only supports a single sort on %s", c.field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-constant-score-query.html
+type constantScoreQuery struct {
+	filterItem query
+	boost      *float64
+}
+
+// NewConstantScoreQuery creates and initializes a new constantScoreQuery.
+func NewConstantScoreQuery(filter query) *constantScoreQuery {
+	return &constantScoreQuery{filterItem: filter}
+}
+
+// Boost sets the constant score returned for every matching document.
+func (q *constantScoreQuery) Boost(boost float64) *constantScoreQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the constant_score query.
+func (q *constantScoreQuery) Build() (interface{}, error) {
+	if q.filterItem == nil {
+		return nil, fmt.Errorf("constant_score: filter can not be empty")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["constant_score"] = params
+
+	src, err := q.filterItem.Build()
+	if err != nil {
+		return nil, err
+	}
+	params["filter"] = src
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
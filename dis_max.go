@@ -0,0 +1,65 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-dis-max-query.html
+type disMaxQuery struct {
+	queryItems []query
+	tieBreaker *float64
+	boost      *float64
+}
+
+// NewDisMaxQuery creates and initializes a new disMaxQuery.
+func NewDisMaxQuery(queries ...query) *disMaxQuery {
+	return &disMaxQuery{
+		queryItems: append(make([]query, 0), queries...),
+	}
+}
+
+// Query adds one or more queries to the dis_max query.
+func (q *disMaxQuery) Query(queries ...query) *disMaxQuery {
+	q.queryItems = append(q.queryItems, queries...)
+	return q
+}
+
+// TieBreaker is used to increase the relevance scores of documents
+// matching multiple query clauses. It must be in [0, 1].
+func (q *disMaxQuery) TieBreaker(tieBreaker float64) *disMaxQuery {
+	q.tieBreaker = &tieBreaker
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *disMaxQuery) Boost(boost float64) *disMaxQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the dis_max query.
+func (q *disMaxQuery) Build() (interface{}, error) {
+	if len(q.queryItems) == 0 {
+		return nil, fmt.Errorf("dis_max: at least one query must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["dis_max"] = params
+
+	queries := make([]interface{}, 0, len(q.queryItems))
+	for _, subQuery := range q.queryItems {
+		src, err := subQuery.Build()
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, src)
+	}
+	params["queries"] = queries
+
+	if q.tieBreaker != nil {
+		params["tie_breaker"] = *q.tieBreaker
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
@@ -0,0 +1,144 @@
+package esbuilder
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dissectKey is a %{...} key of a dissect pattern followed by the literal
+// delimiter up to the next key.
+type dissectKey struct {
+	name         string
+	modifier     byte
+	order        int
+	rightPadding bool
+	delimiter    string
+}
+
+// dissectPattern is a parsed dissect pattern.
+type dissectPattern struct {
+	prefix string
+	keys   []*dissectKey
+}
+
+// parseDissect parses a dissect pattern. Keys support the "->" right
+// padding suffix and the "+" append, "+name/n" ordered append, "?" named
+// skip and "*"/"&" reference modifiers.
+func parseDissect(pattern string) (*dissectPattern, error) {
+	d := &dissectPattern{}
+	rest := pattern
+	start := strings.Index(rest, "%{")
+	if start < 0 {
+		return nil, fmt.Errorf("dissect: pattern %q has no keys", pattern)
+	}
+	d.prefix = rest[:start]
+	rest = rest[start:]
+	for len(rest) > 0 {
+		end := strings.Index(rest, "}")
+		if end < 0 {
+			return nil, fmt.Errorf("dissect: unclosed key in pattern %q", pattern)
+		}
+		key := &dissectKey{name: rest[2:end]}
+		rest = rest[end+1:]
+		if strings.HasSuffix(key.name, "->") {
+			key.rightPadding = true
+			key.name = strings.TrimSuffix(key.name, "->")
+		}
+		if key.name != "" && strings.ContainsRune("+?*&", rune(key.name[0])) {
+			key.modifier = key.name[0]
+			key.name = key.name[1:]
+		}
+		if key.modifier == '+' {
+			if slash := strings.LastIndex(key.name, "/"); slash >= 0 {
+				order, err := strconv.Atoi(key.name[slash+1:])
+				if err != nil {
+					return nil, fmt.Errorf("dissect: invalid append order in key %q", key.name)
+				}
+				key.order = order
+				key.name = key.name[:slash]
+			}
+		}
+		next := strings.Index(rest, "%{")
+		if next < 0 {
+			next = len(rest)
+		}
+		key.delimiter = rest[:next]
+		rest = rest[next:]
+		if key.delimiter == "" && len(rest) > 0 {
+			return nil, fmt.Errorf("dissect: keys of pattern %q must be separated by a delimiter", pattern)
+		}
+		d.keys = append(d.keys, key)
+	}
+	return d, nil
+}
+
+// match dissects text into its fields.
+func (d *dissectPattern) match(text, appendSeparator string) (map[string]string, error) {
+	if !strings.HasPrefix(text, d.prefix) {
+		return nil, fmt.Errorf("dissect: unable to find match for pattern against source [%s]", text)
+	}
+	pos := len(d.prefix)
+	values := make([]string, len(d.keys))
+	for i, key := range d.keys {
+		if key.delimiter == "" {
+			values[i] = text[pos:]
+			pos = len(text)
+			continue
+		}
+		idx := strings.Index(text[pos:], key.delimiter)
+		if idx < 0 {
+			return nil, fmt.Errorf("dissect: unable to find match for pattern against source [%s]", text)
+		}
+		values[i] = text[pos : pos+idx]
+		pos += idx + len(key.delimiter)
+		if key.rightPadding {
+			for strings.HasPrefix(text[pos:], key.delimiter) {
+				pos += len(key.delimiter)
+			}
+		}
+	}
+
+	appendNames := make(map[string]bool)
+	for _, key := range d.keys {
+		if key.modifier == '+' {
+			appendNames[key.name] = true
+		}
+	}
+	fields := make(map[string]string)
+	appended := make(map[string][]int)
+	referenceNames := make(map[string]string)
+	referenceValues := make(map[string]string)
+	for i, key := range d.keys {
+		switch {
+		case key.name == "" || key.modifier == '?':
+		case key.modifier == '*':
+			referenceNames[key.name] = values[i]
+		case key.modifier == '&':
+			referenceValues[key.name] = values[i]
+		case appendNames[key.name]:
+			appended[key.name] = append(appended[key.name], i)
+		default:
+			fields[key.name] = values[i]
+		}
+	}
+	for name, indexes := range appended {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return d.keys[indexes[a]].order < d.keys[indexes[b]].order
+		})
+		parts := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			parts = append(parts, values[i])
+		}
+		fields[name] = strings.Join(parts, appendSeparator)
+	}
+	for name, field := range referenceNames {
+		value, ok := referenceValues[name]
+		if !ok {
+			return nil, fmt.Errorf("dissect: reference key %s has no matching value key", name)
+		}
+		fields[field] = value
+	}
+	return fields, nil
+}
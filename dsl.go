@@ -1,31 +1,122 @@
 package esbuilder
 
-import jsoniter "github.com/json-iterator/go"
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
 
 type dsl struct {
-	QueryDsl    query    `json:"query"`
-	Source      []string `json:"_source,omitempty"`
-	Size        int64    `json:"size,omitempty"`
-	From        int64    `json:"from,omitempty"`
-	OrderItems  []query  `json:"sort,omitempty"`
-	TrackTotal  bool     `json:"track_total_hits,omitempty"`
-	SearchAfter []any    `json:"search_after,omitempty"`
-	Pit         query    `json:"pit,omitempty"`
-	Aggs        query    `json:"aggs,omitempty"`
+	QueryDsl        query                    `json:"query"`
+	Source          []string                 `json:"_source,omitempty"`
+	Size            int64                    `json:"size,omitempty"`
+	From            int64                    `json:"from,omitempty"`
+	OrderItems      []query                  `json:"sort,omitempty"`
+	TrackTotal      bool                     `json:"track_total_hits,omitempty"`
+	SearchAfter     []any                    `json:"search_after,omitempty"`
+	Pit             query                    `json:"pit,omitempty"`
+	Aggs            query                    `json:"aggs,omitempty"`
+	Highlight       query                    `json:"highlight,omitempty"`
+	Suggest         query                    `json:"suggest,omitempty"`
+	Collapse        *collapse                `json:"collapse,omitempty"`
+	Rescore         []query                  `json:"rescore,omitempty"`
+	Ext             query                    `json:"ext,omitempty"`
+	SourceExcludes  []string                 `json:"-"`
+	FetchSource     *bool                    `json:"-"`
+	Fields          []any                    `json:"fields,omitempty"`
+	DocvalueFields  []any                    `json:"docvalue_fields,omitempty"`
+	StoredFields    []string                 `json:"stored_fields,omitempty"`
+	ScriptFields    map[string]*scriptField  `json:"script_fields,omitempty"`
+	RuntimeMappings map[string]*runtimeField `json:"runtime_mappings,omitempty"`
+	MinScore        *float64                 `json:"min_score,omitempty"`
+	Timeout         string                   `json:"timeout,omitempty"`
+	TerminateAfter  int64                    `json:"terminate_after,omitempty"`
+	TrackTotalUpTo  int64                    `json:"-"`
+	TrackScores     bool                     `json:"track_scores,omitempty"`
+	Explain         bool                     `json:"explain,omitempty"`
+	Version         bool                     `json:"version,omitempty"`
+	SeqNoPrimary    bool                     `json:"seq_no_primary_term,omitempty"`
+	Stats           []string                 `json:"stats,omitempty"`
+	IndicesBoost    []map[string]float64     `json:"indices_boost,omitempty"`
+	PostFilter      query                    `json:"post_filter,omitempty"`
+	Profile         bool                     `json:"profile,omitempty"`
+	Params          url.Values               `json:"-"`
 }
 
 func NewDsl() *dsl {
 	return &dsl{
-		Source:      make([]string, 0),
-		OrderItems:  make([]query, 0),
-		SearchAfter: make([]any, 0),
-		TrackTotal:  false,
+		Source:          make([]string, 0),
+		OrderItems:      make([]query, 0),
+		SearchAfter:     make([]any, 0),
+		Rescore:         make([]query, 0),
+		TrackTotal:      false,
+		SourceExcludes:  make([]string, 0),
+		Fields:          make([]any, 0),
+		DocvalueFields:  make([]any, 0),
+		StoredFields:    make([]string, 0),
+		ScriptFields:    make(map[string]*scriptField),
+		RuntimeMappings: make(map[string]*runtimeField),
+		Stats:           make([]string, 0),
+		IndicesBoost:    make([]map[string]float64, 0),
+		Params:          make(url.Values),
 	}
 }
 
 func (dsl *dsl) AddSource(source []string) {
 	dsl.Source = append(dsl.Source, source...)
 }
+
+// AddSourceExcludes excludes fields from the returned _source.
+func (dsl *dsl) AddSourceExcludes(excludes []string) {
+	dsl.SourceExcludes = append(dsl.SourceExcludes, excludes...)
+}
+
+// SetFetchSource disables returning _source when fetch is false.
+func (dsl *dsl) SetFetchSource(fetch bool) {
+	dsl.FetchSource = &fetch
+}
+
+// AddFields retrieves fields, wildcard patterns allowed, from the mapping.
+func (dsl *dsl) AddFields(fields ...string) {
+	for _, field := range fields {
+		dsl.Fields = append(dsl.Fields, field)
+	}
+}
+
+// AddFieldWithFormat retrieves field formatted with format, e.g. a date format.
+func (dsl *dsl) AddFieldWithFormat(field, format string) {
+	dsl.Fields = append(dsl.Fields, map[string]any{"field": field, "format": format})
+}
+
+// AddDocvalueFields retrieves fields from doc values.
+func (dsl *dsl) AddDocvalueFields(fields ...string) {
+	for _, field := range fields {
+		dsl.DocvalueFields = append(dsl.DocvalueFields, field)
+	}
+}
+
+// AddDocvalueFieldWithFormat retrieves field from doc values formatted with format.
+func (dsl *dsl) AddDocvalueFieldWithFormat(field, format string) {
+	dsl.DocvalueFields = append(dsl.DocvalueFields, map[string]any{"field": field, "format": format})
+}
+
+// AddStoredFields retrieves stored fields. Use "_none_" to disable
+// stored fields and _source entirely.
+func (dsl *dsl) AddStoredFields(fields ...string) {
+	dsl.StoredFields = append(dsl.StoredFields, fields...)
+}
+
+func (dsl *dsl) AddScriptField(name string, field *scriptField) {
+	dsl.ScriptFields[name] = field
+}
+
+func (dsl *dsl) AddRuntimeField(name string, field *runtimeField) {
+	dsl.RuntimeMappings[name] = field
+}
+
 func (dsl *dsl) SetQuery(query query) {
 	dsl.QueryDsl = query
 }
@@ -34,6 +125,26 @@ func (dsl *dsl) SetAggs(aggs query) {
 	dsl.Aggs = aggs
 }
 
+func (dsl *dsl) SetHighlight(highlight query) {
+	dsl.Highlight = highlight
+}
+
+func (dsl *dsl) SetSuggest(suggest query) {
+	dsl.Suggest = suggest
+}
+
+func (dsl *dsl) SetCollapse(collapse *collapse) {
+	dsl.Collapse = collapse
+}
+
+func (dsl *dsl) AddRescore(rescore query) {
+	dsl.Rescore = append(dsl.Rescore, rescore)
+}
+
+func (dsl *dsl) SetExt(ext query) {
+	dsl.Ext = ext
+}
+
 func (dsl *dsl) SetSize(size int64) {
 	dsl.Size = size
 }
@@ -48,6 +159,111 @@ func (dsl *dsl) SetTrackTotal(track bool) {
 	dsl.TrackTotal = track
 }
 
+// SetTrackTotalHitsUpTo counts hits accurately up to threshold.
+func (dsl *dsl) SetTrackTotalHitsUpTo(threshold int64) {
+	dsl.TrackTotalUpTo = threshold
+}
+
+// SetMinScore excludes documents with a score lower than minScore.
+func (dsl *dsl) SetMinScore(minScore float64) {
+	dsl.MinScore = &minScore
+}
+
+// SetTimeout bounds the time each shard spends on the search. It is sent
+// in milliseconds, rounded up so a positive timeout never becomes 0ms.
+func (dsl *dsl) SetTimeout(timeout time.Duration) {
+	ms := timeout.Milliseconds()
+	if timeout > time.Duration(ms)*time.Millisecond {
+		ms++
+	}
+	dsl.Timeout = strconv.FormatInt(ms, 10) + "ms"
+}
+
+// SetTerminateAfter is the maximum number of documents collected per shard.
+func (dsl *dsl) SetTerminateAfter(terminateAfter int64) {
+	dsl.TerminateAfter = terminateAfter
+}
+
+// SetTrackScores computes scores even when sorting on a field.
+func (dsl *dsl) SetTrackScores(trackScores bool) {
+	dsl.TrackScores = trackScores
+}
+
+// SetExplain returns detailed information about score computation.
+func (dsl *dsl) SetExplain(explain bool) {
+	dsl.Explain = explain
+}
+
+// SetVersion returns the document version with each hit.
+func (dsl *dsl) SetVersion(version bool) {
+	dsl.Version = version
+}
+
+// SetSeqNoPrimaryTerm returns the sequence number and primary term of each hit.
+func (dsl *dsl) SetSeqNoPrimaryTerm(seqNoPrimaryTerm bool) {
+	dsl.SeqNoPrimary = seqNoPrimaryTerm
+}
+
+// AddStats tags the request with statistics groups.
+func (dsl *dsl) AddStats(groups ...string) {
+	dsl.Stats = append(dsl.Stats, groups...)
+}
+
+// AddIndicesBoost boosts documents of index, wildcard patterns allowed.
+func (dsl *dsl) AddIndicesBoost(index string, boost float64) {
+	dsl.IndicesBoost = append(dsl.IndicesBoost, map[string]float64{index: boost})
+}
+
+// SetPostFilter filters hits after aggregations are computed.
+func (dsl *dsl) SetPostFilter(filter query) {
+	dsl.PostFilter = filter
+}
+
+// SetProfile returns timing information about the search execution.
+func (dsl *dsl) SetProfile(profile bool) {
+	dsl.Profile = profile
+}
+
+// SetRouting routes the search to the shards of the given routing values.
+func (dsl *dsl) SetRouting(routing ...string) {
+	dsl.Params.Set("routing", strings.Join(routing, ","))
+}
+
+// SetPreference selects the nodes and shards used for the search.
+func (dsl *dsl) SetPreference(preference string) {
+	dsl.Params.Set("preference", preference)
+}
+
+// SetRequestCache enables or disables the shard request cache.
+func (dsl *dsl) SetRequestCache(requestCache bool) {
+	dsl.Params.Set("request_cache", strconv.FormatBool(requestCache))
+}
+
+// SetSearchType is query_then_fetch (default) or dfs_query_then_fetch.
+func (dsl *dsl) SetSearchType(searchType string) {
+	dsl.Params.Set("search_type", searchType)
+}
+
+// SetAllowPartialSearchResults returns partial results on shard failures.
+func (dsl *dsl) SetAllowPartialSearchResults(allow bool) {
+	dsl.Params.Set("allow_partial_search_results", strconv.FormatBool(allow))
+}
+
+// SetIgnoreUnavailable ignores missing or closed indices.
+func (dsl *dsl) SetIgnoreUnavailable(ignoreUnavailable bool) {
+	dsl.Params.Set("ignore_unavailable", strconv.FormatBool(ignoreUnavailable))
+}
+
+// SetScroll keeps the search context alive for scrolling, e.g. "1m".
+func (dsl *dsl) SetScroll(keepAlive string) {
+	dsl.Params.Set("scroll", keepAlive)
+}
+
+// URLParams returns the parameters sent in the URL of the search request.
+func (dsl *dsl) URLParams() url.Values {
+	return dsl.Params
+}
+
 func (dsl *dsl) SetSearchAfter(searchAfter []any) *dsl {
 	dsl.SearchAfter = searchAfter
 	return dsl
@@ -56,9 +272,13 @@ func (dsl *dsl) SetPit(pit query) {
 	dsl.Pit = pit
 }
 func (dsl *dsl) Build() (any, error) {
-	mapQuery, _ := dsl.QueryDsl.Build()
-	mapDsl := map[string]any{
-		"query": mapQuery,
+	mapDsl := map[string]any{}
+	if dsl.QueryDsl != nil {
+		mapQuery, err := dsl.QueryDsl.Build()
+		if err != nil {
+			return nil, err
+		}
+		mapDsl["query"] = mapQuery
 	}
 	if dsl.Size > 0 {
 		mapDsl["size"] = dsl.Size
@@ -68,10 +288,53 @@ func (dsl *dsl) Build() (any, error) {
 		mapDsl["from"] = dsl.From
 	}
 
-	if len(dsl.Source) > 0 {
+	// _source
+	if dsl.FetchSource != nil && !*dsl.FetchSource {
+		mapDsl["_source"] = false
+	} else if len(dsl.SourceExcludes) > 0 {
+		source := map[string]any{
+			"excludes": dsl.SourceExcludes,
+		}
+		if len(dsl.Source) > 0 {
+			source["includes"] = dsl.Source
+		}
+		mapDsl["_source"] = source
+	} else if len(dsl.Source) > 0 {
 		mapDsl["_source"] = dsl.Source
 	}
 
+	if len(dsl.Fields) > 0 {
+		mapDsl["fields"] = dsl.Fields
+	}
+	if len(dsl.DocvalueFields) > 0 {
+		mapDsl["docvalue_fields"] = dsl.DocvalueFields
+	}
+	if len(dsl.StoredFields) > 0 {
+		mapDsl["stored_fields"] = dsl.StoredFields
+	}
+	if len(dsl.ScriptFields) > 0 {
+		scriptFields := make(map[string]any)
+		for name, field := range dsl.ScriptFields {
+			src, err := field.Build()
+			if err != nil {
+				return nil, err
+			}
+			scriptFields[name] = src
+		}
+		mapDsl["script_fields"] = scriptFields
+	}
+	if len(dsl.RuntimeMappings) > 0 {
+		runtimeMappings := make(map[string]any)
+		for name, field := range dsl.RuntimeMappings {
+			src, err := field.Build()
+			if err != nil {
+				return nil, err
+			}
+			runtimeMappings[name] = src
+		}
+		mapDsl["runtime_mappings"] = runtimeMappings
+	}
+
 	if len(dsl.SearchAfter) > 0 {
 		mapDsl["search_after"] = dsl.SearchAfter
 	}
@@ -93,7 +356,9 @@ func (dsl *dsl) Build() (any, error) {
 		}
 		mapDsl["sort"] = clauses
 	}
-	if dsl.TrackTotal {
+	if dsl.TrackTotalUpTo > 0 {
+		mapDsl["track_total_hits"] = dsl.TrackTotalUpTo
+	} else if dsl.TrackTotal {
 		mapDsl["track_total_hits"] = true
 	}
 	if dsl.Pit != nil {
@@ -111,6 +376,98 @@ func (dsl *dsl) Build() (any, error) {
 		}
 		mapDsl["aggs"] = src
 	}
+
+	if dsl.Highlight != nil {
+		src, err := dsl.Highlight.Build()
+		if err != nil {
+			return nil, err
+		}
+		mapDsl["highlight"] = src
+	}
+
+	if dsl.Suggest != nil {
+		src, err := dsl.Suggest.Build()
+		if err != nil {
+			return nil, err
+		}
+		mapDsl["suggest"] = src
+	}
+
+	if dsl.Collapse != nil {
+		if err := dsl.Collapse.validate(dsl); err != nil {
+			return nil, err
+		}
+		src, err := dsl.Collapse.Build()
+		if err != nil {
+			return nil, err
+		}
+		mapDsl["collapse"] = src
+	}
+
+	// rescore
+	if len(dsl.Rescore) == 1 {
+		src, err := dsl.Rescore[0].Build()
+		if err != nil {
+			return nil, err
+		}
+		mapDsl["rescore"] = src
+	} else if len(dsl.Rescore) > 1 {
+		var clauses []interface{}
+		for _, rescore := range dsl.Rescore {
+			src, err := rescore.Build()
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, src)
+		}
+		mapDsl["rescore"] = clauses
+	}
+
+	if dsl.Ext != nil {
+		src, err := dsl.Ext.Build()
+		if err != nil {
+			return nil, err
+		}
+		mapDsl["ext"] = src
+	}
+
+	if dsl.MinScore != nil {
+		mapDsl["min_score"] = *dsl.MinScore
+	}
+	if dsl.Timeout != "" {
+		mapDsl["timeout"] = dsl.Timeout
+	}
+	if dsl.TerminateAfter > 0 {
+		mapDsl["terminate_after"] = dsl.TerminateAfter
+	}
+	if dsl.TrackScores {
+		mapDsl["track_scores"] = true
+	}
+	if dsl.Explain {
+		mapDsl["explain"] = true
+	}
+	if dsl.Version {
+		mapDsl["version"] = true
+	}
+	if dsl.SeqNoPrimary {
+		mapDsl["seq_no_primary_term"] = true
+	}
+	if len(dsl.Stats) > 0 {
+		mapDsl["stats"] = dsl.Stats
+	}
+	if len(dsl.IndicesBoost) > 0 {
+		mapDsl["indices_boost"] = dsl.IndicesBoost
+	}
+	if dsl.PostFilter != nil {
+		src, err := dsl.PostFilter.Build()
+		if err != nil {
+			return nil, err
+		}
+		mapDsl["post_filter"] = src
+	}
+	if dsl.Profile {
+		mapDsl["profile"] = true
+	}
 	return mapDsl, nil
 }
 
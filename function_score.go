@@ -0,0 +1,424 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-function-score-query.html
+type functionScoreQuery struct {
+	queryItem query
+	functions []query
+	scoreMode string
+	boostMode string
+	maxBoost  *float64
+	minScore  *float64
+	boost     *float64
+}
+
+// NewFunctionScoreQuery creates and initializes a new functionScoreQuery
+// wrapping the given query.
+func NewFunctionScoreQuery(q query) *functionScoreQuery {
+	return &functionScoreQuery{
+		queryItem: q,
+		functions: make([]query, 0),
+	}
+}
+
+// Query sets the query whose score is modified.
+func (q *functionScoreQuery) Query(query query) *functionScoreQuery {
+	q.queryItem = query
+	return q
+}
+
+// Add adds score functions to the query.
+func (q *functionScoreQuery) Add(functions ...query) *functionScoreQuery {
+	q.functions = append(q.functions, functions...)
+	return q
+}
+
+// ScoreMode specifies how the computed scores are combined:
+// multiply (default), sum, avg, first, max or min.
+func (q *functionScoreQuery) ScoreMode(scoreMode string) *functionScoreQuery {
+	q.scoreMode = scoreMode
+	return q
+}
+
+// BoostMode specifies how the function score is combined with the query
+// score: multiply (default), replace, sum, avg, max or min.
+func (q *functionScoreQuery) BoostMode(boostMode string) *functionScoreQuery {
+	q.boostMode = boostMode
+	return q
+}
+
+// MaxBoost restricts the new score to not exceed the provided limit.
+func (q *functionScoreQuery) MaxBoost(maxBoost float64) *functionScoreQuery {
+	q.maxBoost = &maxBoost
+	return q
+}
+
+// MinScore excludes documents that do not meet the provided score threshold.
+func (q *functionScoreQuery) MinScore(minScore float64) *functionScoreQuery {
+	q.minScore = &minScore
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *functionScoreQuery) Boost(boost float64) *functionScoreQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the function score query.
+func (q *functionScoreQuery) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	fq := make(map[string]interface{})
+	source["function_score"] = fq
+
+	if q.queryItem != nil {
+		src, err := q.queryItem.Build()
+		if err != nil {
+			return nil, err
+		}
+		fq["query"] = src
+	}
+
+	if len(q.functions) > 0 {
+		var functions []interface{}
+		for _, fn := range q.functions {
+			src, err := fn.Build()
+			if err != nil {
+				return nil, err
+			}
+			functions = append(functions, src)
+		}
+		fq["functions"] = functions
+	}
+
+	if q.scoreMode != "" {
+		fq["score_mode"] = q.scoreMode
+	}
+	if q.boostMode != "" {
+		fq["boost_mode"] = q.boostMode
+	}
+	if q.maxBoost != nil {
+		fq["max_boost"] = *q.maxBoost
+	}
+	if q.minScore != nil {
+		fq["min_score"] = *q.minScore
+	}
+	if q.boost != nil {
+		fq["boost"] = *q.boost
+	}
+	return source, nil
+}
+
+// buildScoreFunction adds the optional filter and weight shared by every
+// score function to source.
+func buildScoreFunction(source map[string]interface{}, filter query, weight *float64) error {
+	if filter != nil {
+		src, err := filter.Build()
+		if err != nil {
+			return err
+		}
+		source["filter"] = src
+	}
+	if weight != nil {
+		source["weight"] = *weight
+	}
+	return nil
+}
+
+// weightFunction multiplies the score by the provided weight.
+type weightFunction struct {
+	weight     float64
+	filterItem query
+}
+
+// NewWeightFunction creates and initializes a new weightFunction.
+func NewWeightFunction(weight float64) *weightFunction {
+	return &weightFunction{weight: weight}
+}
+
+// Filter restricts the function to documents matching filter.
+func (f *weightFunction) Filter(filter query) *weightFunction {
+	f.filterItem = filter
+	return f
+}
+
+func (f *weightFunction) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	if err := buildScoreFunction(source, f.filterItem, &f.weight); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// fieldValueFactorFunction uses a document field to influence the score.
+type fieldValueFactorFunction struct {
+	field      string
+	factor     *float64
+	modifier   string
+	missing    *float64
+	weight     *float64
+	filterItem query
+}
+
+// NewFieldValueFactorFunction creates and initializes a new fieldValueFactorFunction.
+func NewFieldValueFactorFunction(field string) *fieldValueFactorFunction {
+	return &fieldValueFactorFunction{field: field}
+}
+
+// Factor is multiplied with the field value.
+func (f *fieldValueFactorFunction) Factor(factor float64) *fieldValueFactorFunction {
+	f.factor = &factor
+	return f
+}
+
+// Modifier is applied to the field value: none, log, log1p, log2p,
+// ln, ln1p, ln2p, square, sqrt or reciprocal.
+func (f *fieldValueFactorFunction) Modifier(modifier string) *fieldValueFactorFunction {
+	f.modifier = modifier
+	return f
+}
+
+// Missing is used for documents that do not have the field.
+func (f *fieldValueFactorFunction) Missing(missing float64) *fieldValueFactorFunction {
+	f.missing = &missing
+	return f
+}
+
+func (f *fieldValueFactorFunction) Weight(weight float64) *fieldValueFactorFunction {
+	f.weight = &weight
+	return f
+}
+
+func (f *fieldValueFactorFunction) Filter(filter query) *fieldValueFactorFunction {
+	f.filterItem = filter
+	return f
+}
+
+func (f *fieldValueFactorFunction) Build() (interface{}, error) {
+	if f.field == "" {
+		return nil, fmt.Errorf("field_value_factor: field can not be empty")
+	}
+	source := make(map[string]interface{})
+	params := map[string]interface{}{
+		"field": f.field,
+	}
+	if f.factor != nil {
+		params["factor"] = *f.factor
+	}
+	if f.modifier != "" {
+		params["modifier"] = f.modifier
+	}
+	if f.missing != nil {
+		params["missing"] = *f.missing
+	}
+	source["field_value_factor"] = params
+	if err := buildScoreFunction(source, f.filterItem, f.weight); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// randomScoreFunction generates scores that are uniformly distributed in [0, 1).
+type randomScoreFunction struct {
+	seed       interface{}
+	field      string
+	weight     *float64
+	filterItem query
+}
+
+// NewRandomScoreFunction creates and initializes a new randomScoreFunction.
+func NewRandomScoreFunction() *randomScoreFunction {
+	return &randomScoreFunction{}
+}
+
+// Seed makes the scores reproducible. It is only used together with Field.
+func (f *randomScoreFunction) Seed(seed interface{}) *randomScoreFunction {
+	f.seed = seed
+	return f
+}
+
+// Field is the field whose values are combined with the seed,
+// usually "_seq_no".
+func (f *randomScoreFunction) Field(field string) *randomScoreFunction {
+	f.field = field
+	return f
+}
+
+func (f *randomScoreFunction) Weight(weight float64) *randomScoreFunction {
+	f.weight = &weight
+	return f
+}
+
+func (f *randomScoreFunction) Filter(filter query) *randomScoreFunction {
+	f.filterItem = filter
+	return f
+}
+
+func (f *randomScoreFunction) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	if f.seed != nil {
+		params["seed"] = f.seed
+	}
+	if f.field != "" {
+		params["field"] = f.field
+	}
+	source["random_score"] = params
+	if err := buildScoreFunction(source, f.filterItem, f.weight); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// scriptScoreFunction computes the score with a script.
+type scriptScoreFunction struct {
+	script     *script
+	weight     *float64
+	filterItem query
+}
+
+// NewScriptScoreFunction creates and initializes a new scriptScoreFunction.
+func NewScriptScoreFunction(script *script) *scriptScoreFunction {
+	return &scriptScoreFunction{script: script}
+}
+
+func (f *scriptScoreFunction) Weight(weight float64) *scriptScoreFunction {
+	f.weight = &weight
+	return f
+}
+
+func (f *scriptScoreFunction) Filter(filter query) *scriptScoreFunction {
+	f.filterItem = filter
+	return f
+}
+
+func (f *scriptScoreFunction) Build() (interface{}, error) {
+	if f.script == nil {
+		return nil, fmt.Errorf("script_score: script must be set")
+	}
+	script, err := f.script.Build()
+	if err != nil {
+		return nil, err
+	}
+	source := make(map[string]interface{})
+	source["script_score"] = map[string]interface{}{
+		"script": script,
+	}
+	if err := buildScoreFunction(source, f.filterItem, f.weight); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// decayFunction scores a document with a function that decays depending
+// on the distance of a numeric, date or geo field value from an origin.
+type decayFunction struct {
+	decayType      string
+	field          string
+	origin         interface{}
+	scale          interface{}
+	offset         interface{}
+	decay          *float64
+	multiValueMode string
+	weight         *float64
+	filterItem     query
+}
+
+// NewGaussDecayFunction creates a gauss decay function on field.
+func NewGaussDecayFunction(field string) *decayFunction {
+	return &decayFunction{decayType: "gauss", field: field}
+}
+
+// NewLinearDecayFunction creates a linear decay function on field.
+func NewLinearDecayFunction(field string) *decayFunction {
+	return &decayFunction{decayType: "linear", field: field}
+}
+
+// NewExpDecayFunction creates an exp decay function on field.
+func NewExpDecayFunction(field string) *decayFunction {
+	return &decayFunction{decayType: "exp", field: field}
+}
+
+// Origin is the point of origin used for calculating distance. It is a
+// number for numeric fields, a date (or "now") for date fields and a
+// geo point for geo fields.
+func (f *decayFunction) Origin(origin interface{}) *decayFunction {
+	f.origin = origin
+	return f
+}
+
+// Scale defines the distance from origin + offset at which the computed
+// score equals the decay parameter, e.g. 10, "10d" or "2km".
+func (f *decayFunction) Scale(scale interface{}) *decayFunction {
+	f.scale = scale
+	return f
+}
+
+// Offset only computes the decay for documents further than offset from origin.
+func (f *decayFunction) Offset(offset interface{}) *decayFunction {
+	f.offset = offset
+	return f
+}
+
+// Decay defines how documents are scored at the distance given at scale.
+// Defaults to 0.5.
+func (f *decayFunction) Decay(decay float64) *decayFunction {
+	f.decay = &decay
+	return f
+}
+
+// MultiValueMode selects the value used on multi-valued fields:
+// min (default), max, avg or sum.
+func (f *decayFunction) MultiValueMode(mode string) *decayFunction {
+	f.multiValueMode = mode
+	return f
+}
+
+func (f *decayFunction) Weight(weight float64) *decayFunction {
+	f.weight = &weight
+	return f
+}
+
+func (f *decayFunction) Filter(filter query) *decayFunction {
+	f.filterItem = filter
+	return f
+}
+
+func (f *decayFunction) Build() (interface{}, error) {
+	if f.field == "" || f.scale == nil {
+		return nil, fmt.Errorf("%s: field and scale must be set", f.decayType)
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	if f.origin != nil {
+		origin := f.origin
+		if q, ok := origin.(query); ok {
+			src, err := q.Build()
+			if err != nil {
+				return nil, err
+			}
+			origin = src
+		}
+		params["origin"] = origin
+	}
+	params["scale"] = f.scale
+	if f.offset != nil {
+		params["offset"] = f.offset
+	}
+	if f.decay != nil {
+		params["decay"] = *f.decay
+	}
+	fn := map[string]interface{}{
+		f.field: params,
+	}
+	if f.multiValueMode != "" {
+		fn["multi_value_mode"] = f.multiValueMode
+	}
+	source[f.decayType] = fn
+	if err := buildScoreFunction(source, f.filterItem, f.weight); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
@@ -0,0 +1,108 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-geo-bounding-box-query.html
+type geoBoundingBoxQuery struct {
+	name             string
+	topLeft          *geoPoint
+	bottomRight      *geoPoint
+	wkt              string
+	validationMethod string
+	ignoreUnmapped   *bool
+	queryName        string
+	boost            *float64
+}
+
+// NewGeoBoundingBoxQuery creates and initializes a new geoBoundingBoxQuery.
+func NewGeoBoundingBoxQuery(name string) *geoBoundingBoxQuery {
+	return &geoBoundingBoxQuery{name: name}
+}
+
+// TopLeft sets the top left corner of the box.
+func (q *geoBoundingBoxQuery) TopLeft(point *geoPoint) *geoBoundingBoxQuery {
+	q.topLeft = point
+	return q
+}
+
+// BottomRight sets the bottom right corner of the box.
+func (q *geoBoundingBoxQuery) BottomRight(point *geoPoint) *geoBoundingBoxQuery {
+	q.bottomRight = point
+	return q
+}
+
+// WKT sets the box as a WKT BBOX, e.g. "BBOX (-74.1, -71.12, 40.73, 40.01)".
+// It takes precedence over TopLeft and BottomRight.
+func (q *geoBoundingBoxQuery) WKT(wkt string) *geoBoundingBoxQuery {
+	q.wkt = wkt
+	return q
+}
+
+// ValidationMethod is IGNORE_MALFORMED, COERCE or STRICT (default).
+func (q *geoBoundingBoxQuery) ValidationMethod(method string) *geoBoundingBoxQuery {
+	q.validationMethod = method
+	return q
+}
+
+// IgnoreUnmapped ignores an unmapped field instead of returning an error.
+func (q *geoBoundingBoxQuery) IgnoreUnmapped(ignoreUnmapped bool) *geoBoundingBoxQuery {
+	q.ignoreUnmapped = &ignoreUnmapped
+	return q
+}
+
+// QueryName sets the query name for the filter.
+func (q *geoBoundingBoxQuery) QueryName(queryName string) *geoBoundingBoxQuery {
+	q.queryName = queryName
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *geoBoundingBoxQuery) Boost(boost float64) *geoBoundingBoxQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the geo_bounding_box query.
+func (q *geoBoundingBoxQuery) Build() (interface{}, error) {
+	if q.name == "" {
+		return nil, fmt.Errorf("geo_bounding_box: field must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["geo_bounding_box"] = params
+
+	box := make(map[string]interface{})
+	if q.wkt != "" {
+		box["wkt"] = q.wkt
+	} else {
+		if q.topLeft == nil || q.bottomRight == nil {
+			return nil, fmt.Errorf("geo_bounding_box: top_left and bottom_right must be set")
+		}
+		topLeft, err := q.topLeft.Build()
+		if err != nil {
+			return nil, err
+		}
+		bottomRight, err := q.bottomRight.Build()
+		if err != nil {
+			return nil, err
+		}
+		box["top_left"] = topLeft
+		box["bottom_right"] = bottomRight
+	}
+	params[q.name] = box
+
+	if q.validationMethod != "" {
+		params["validation_method"] = q.validationMethod
+	}
+	if q.ignoreUnmapped != nil {
+		params["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.queryName != "" {
+		params["_name"] = q.queryName
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
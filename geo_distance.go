@@ -0,0 +1,96 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-geo-distance-query.html
+type geoDistanceQuery struct {
+	name             string
+	point            *geoPoint
+	distance         string
+	distanceType     string
+	validationMethod string
+	ignoreUnmapped   *bool
+	queryName        string
+	boost            *float64
+}
+
+// NewGeoDistanceQuery creates and initializes a new geoDistanceQuery.
+func NewGeoDistanceQuery(name string) *geoDistanceQuery {
+	return &geoDistanceQuery{name: name}
+}
+
+// Point sets the center of the circle.
+func (q *geoDistanceQuery) Point(point *geoPoint) *geoDistanceQuery {
+	q.point = point
+	return q
+}
+
+// Distance is the radius of the circle, e.g. "12km".
+func (q *geoDistanceQuery) Distance(distance string) *geoDistanceQuery {
+	q.distance = distance
+	return q
+}
+
+// DistanceType is arc (default) or plane.
+func (q *geoDistanceQuery) DistanceType(distanceType string) *geoDistanceQuery {
+	q.distanceType = distanceType
+	return q
+}
+
+// ValidationMethod is IGNORE_MALFORMED, COERCE or STRICT (default).
+func (q *geoDistanceQuery) ValidationMethod(method string) *geoDistanceQuery {
+	q.validationMethod = method
+	return q
+}
+
+// IgnoreUnmapped ignores an unmapped field instead of returning an error.
+func (q *geoDistanceQuery) IgnoreUnmapped(ignoreUnmapped bool) *geoDistanceQuery {
+	q.ignoreUnmapped = &ignoreUnmapped
+	return q
+}
+
+// QueryName sets the query name for the filter.
+func (q *geoDistanceQuery) QueryName(queryName string) *geoDistanceQuery {
+	q.queryName = queryName
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *geoDistanceQuery) Boost(boost float64) *geoDistanceQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the geo_distance query.
+func (q *geoDistanceQuery) Build() (interface{}, error) {
+	if q.name == "" || q.point == nil || q.distance == "" {
+		return nil, fmt.Errorf("geo_distance: field, point and distance must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["geo_distance"] = params
+
+	point, err := q.point.Build()
+	if err != nil {
+		return nil, err
+	}
+	params[q.name] = point
+	params["distance"] = q.distance
+	if q.distanceType != "" {
+		params["distance_type"] = q.distanceType
+	}
+	if q.validationMethod != "" {
+		params["validation_method"] = q.validationMethod
+	}
+	if q.ignoreUnmapped != nil {
+		params["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.queryName != "" {
+		params["_name"] = q.queryName
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
@@ -0,0 +1,98 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/sort-search-results.html#geo-sorting
+type geoDistanceSort struct {
+	name           string
+	points         []*geoPoint
+	order          string
+	unit           string
+	distanceType   string
+	mode           string
+	ignoreUnmapped *bool
+}
+
+// NewGeoDistanceSort creates and initializes a new geoDistanceSort.
+func NewGeoDistanceSort(name string, points ...*geoPoint) *geoDistanceSort {
+	return &geoDistanceSort{
+		name:   name,
+		points: append(make([]*geoPoint, 0), points...),
+	}
+}
+
+// Order is asc (default) or desc.
+func (s *geoDistanceSort) Order(order string) *geoDistanceSort {
+	s.order = order
+	return s
+}
+
+// Unit is the unit used for the sort values, e.g. km. Defaults to m.
+func (s *geoDistanceSort) Unit(unit string) *geoDistanceSort {
+	s.unit = unit
+	return s
+}
+
+// DistanceType is arc (default) or plane.
+func (s *geoDistanceSort) DistanceType(distanceType string) *geoDistanceSort {
+	s.distanceType = distanceType
+	return s
+}
+
+// Mode selects the distance used for multi-valued fields: min, max,
+// median or avg.
+func (s *geoDistanceSort) Mode(mode string) *geoDistanceSort {
+	s.mode = mode
+	return s
+}
+
+// IgnoreUnmapped treats an unmapped field as a missing value.
+func (s *geoDistanceSort) IgnoreUnmapped(ignoreUnmapped bool) *geoDistanceSort {
+	s.ignoreUnmapped = &ignoreUnmapped
+	return s
+}
+
+// Build returns the map for the _geo_distance sort.
+func (s *geoDistanceSort) Build() (interface{}, error) {
+	if s.name == "" || len(s.points) == 0 {
+		return nil, fmt.Errorf("_geo_distance: field and points must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["_geo_distance"] = params
+
+	if len(s.points) == 1 {
+		src, err := s.points[0].Build()
+		if err != nil {
+			return nil, err
+		}
+		params[s.name] = src
+	} else {
+		var points []interface{}
+		for _, point := range s.points {
+			src, err := point.Build()
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, src)
+		}
+		params[s.name] = points
+	}
+	if s.order != "" {
+		params["order"] = s.order
+	}
+	if s.unit != "" {
+		params["unit"] = s.unit
+	}
+	if s.distanceType != "" {
+		params["distance_type"] = s.distanceType
+	}
+	if s.mode != "" {
+		params["mode"] = s.mode
+	}
+	if s.ignoreUnmapped != nil {
+		params["ignore_unmapped"] = *s.ignoreUnmapped
+	}
+	return source, nil
+}
@@ -0,0 +1,48 @@
+package esbuilder
+
+import "fmt"
+
+// geoPoint is a location given as lat/lon, a geohash or a WKT POINT.
+// For details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/geo-point.html
+type geoPoint struct {
+	lat     float64
+	lon     float64
+	geohash string
+	wkt     string
+}
+
+// NewGeoPoint creates a geoPoint from latitude and longitude.
+func NewGeoPoint(lat, lon float64) *geoPoint {
+	return &geoPoint{lat: lat, lon: lon}
+}
+
+// NewGeoPointFromGeohash creates a geoPoint from a geohash.
+func NewGeoPointFromGeohash(geohash string) *geoPoint {
+	return &geoPoint{geohash: geohash}
+}
+
+// NewGeoPointFromWKT creates a geoPoint from a WKT POINT, e.g. "POINT (-71.34 41.12)".
+func NewGeoPointFromWKT(wkt string) *geoPoint {
+	return &geoPoint{wkt: wkt}
+}
+
+// Build returns the representation of the point used in requests.
+func (p *geoPoint) Build() (interface{}, error) {
+	if p == nil {
+		return nil, fmt.Errorf("geo point must not be nil")
+	}
+	if p.geohash != "" {
+		return p.geohash, nil
+	}
+	if p.wkt != "" {
+		return p.wkt, nil
+	}
+	if p.lat < -90 || p.lat > 90 || p.lon < -180 || p.lon > 180 {
+		return nil, fmt.Errorf("geo point [%v, %v] out of range", p.lat, p.lon)
+	}
+	return map[string]interface{}{
+		"lat": p.lat,
+		"lon": p.lon,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-geo-polygon-query.html
+type geoPolygonQuery struct {
+	name             string
+	points           []*geoPoint
+	validationMethod string
+	ignoreUnmapped   *bool
+	queryName        string
+	boost            *float64
+}
+
+// NewGeoPolygonQuery creates and initializes a new geoPolygonQuery.
+func NewGeoPolygonQuery(name string, points ...*geoPoint) *geoPolygonQuery {
+	return &geoPolygonQuery{
+		name:   name,
+		points: append(make([]*geoPoint, 0), points...),
+	}
+}
+
+// AddPoint adds points to the polygon.
+func (q *geoPolygonQuery) AddPoint(points ...*geoPoint) *geoPolygonQuery {
+	q.points = append(q.points, points...)
+	return q
+}
+
+// ValidationMethod is IGNORE_MALFORMED, COERCE or STRICT (default).
+func (q *geoPolygonQuery) ValidationMethod(method string) *geoPolygonQuery {
+	q.validationMethod = method
+	return q
+}
+
+// IgnoreUnmapped ignores an unmapped field instead of returning an error.
+func (q *geoPolygonQuery) IgnoreUnmapped(ignoreUnmapped bool) *geoPolygonQuery {
+	q.ignoreUnmapped = &ignoreUnmapped
+	return q
+}
+
+// QueryName sets the query name for the filter.
+func (q *geoPolygonQuery) QueryName(queryName string) *geoPolygonQuery {
+	q.queryName = queryName
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *geoPolygonQuery) Boost(boost float64) *geoPolygonQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the geo_polygon query.
+func (q *geoPolygonQuery) Build() (interface{}, error) {
+	if q.name == "" || len(q.points) < 3 {
+		return nil, fmt.Errorf("geo_polygon: field and at least 3 points must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["geo_polygon"] = params
+
+	var points []interface{}
+	for _, point := range q.points {
+		src, err := point.Build()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, src)
+	}
+	params[q.name] = map[string]interface{}{
+		"points": points,
+	}
+	if q.validationMethod != "" {
+		params["validation_method"] = q.validationMethod
+	}
+	if q.ignoreUnmapped != nil {
+		params["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.queryName != "" {
+		params["_name"] = q.queryName
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
@@ -0,0 +1,143 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-geo-shape-query.html
+type geoShapeQuery struct {
+	name           string
+	shapeType      string
+	coordinates    interface{}
+	wkt            string
+	indexedShape   *indexedShape
+	relation       string
+	ignoreUnmapped *bool
+	queryName      string
+	boost          *float64
+}
+
+// indexedShape references a shape indexed in another index.
+type indexedShape struct {
+	index   string
+	id      string
+	path    string
+	routing string
+}
+
+// NewGeoShapeQuery creates and initializes a new geoShapeQuery.
+func NewGeoShapeQuery(name string) *geoShapeQuery {
+	return &geoShapeQuery{name: name}
+}
+
+// Shape sets an inline GeoJSON shape, e.g. "envelope" with
+// [][]float64{{13.0, 53.0}, {14.0, 52.0}}.
+func (q *geoShapeQuery) Shape(shapeType string, coordinates interface{}) *geoShapeQuery {
+	q.shapeType = shapeType
+	q.coordinates = coordinates
+	return q
+}
+
+// WKT sets an inline shape in WKT, e.g. "POLYGON ((...))".
+func (q *geoShapeQuery) WKT(wkt string) *geoShapeQuery {
+	q.wkt = wkt
+	return q
+}
+
+// IndexedShape uses a pre-indexed shape stored in document id of index.
+func (q *geoShapeQuery) IndexedShape(index, id string) *geoShapeQuery {
+	q.indexedShape = &indexedShape{index: index, id: id}
+	return q
+}
+
+// IndexedShapePath sets the field holding the pre-indexed shape. Defaults to "shape".
+func (q *geoShapeQuery) IndexedShapePath(path string) *geoShapeQuery {
+	if q.indexedShape != nil {
+		q.indexedShape.path = path
+	}
+	return q
+}
+
+// IndexedShapeRouting sets the routing of the pre-indexed shape document.
+func (q *geoShapeQuery) IndexedShapeRouting(routing string) *geoShapeQuery {
+	if q.indexedShape != nil {
+		q.indexedShape.routing = routing
+	}
+	return q
+}
+
+// Relation is intersects (default), disjoint, within or contains.
+func (q *geoShapeQuery) Relation(relation string) *geoShapeQuery {
+	q.relation = relation
+	return q
+}
+
+// IgnoreUnmapped ignores an unmapped field instead of returning an error.
+func (q *geoShapeQuery) IgnoreUnmapped(ignoreUnmapped bool) *geoShapeQuery {
+	q.ignoreUnmapped = &ignoreUnmapped
+	return q
+}
+
+// QueryName sets the query name for the filter.
+func (q *geoShapeQuery) QueryName(queryName string) *geoShapeQuery {
+	q.queryName = queryName
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *geoShapeQuery) Boost(boost float64) *geoShapeQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the geo_shape query.
+func (q *geoShapeQuery) Build() (interface{}, error) {
+	if q.name == "" {
+		return nil, fmt.Errorf("geo_shape: field must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["geo_shape"] = params
+
+	field := make(map[string]interface{})
+	switch {
+	case q.indexedShape != nil:
+		if q.indexedShape.index == "" || q.indexedShape.id == "" {
+			return nil, fmt.Errorf("geo_shape: indexed shape index and id must be set")
+		}
+		shape := map[string]interface{}{
+			"index": q.indexedShape.index,
+			"id":    q.indexedShape.id,
+		}
+		if q.indexedShape.path != "" {
+			shape["path"] = q.indexedShape.path
+		}
+		if q.indexedShape.routing != "" {
+			shape["routing"] = q.indexedShape.routing
+		}
+		field["indexed_shape"] = shape
+	case q.wkt != "":
+		field["shape"] = q.wkt
+	case q.shapeType != "":
+		field["shape"] = map[string]interface{}{
+			"type":        q.shapeType,
+			"coordinates": q.coordinates,
+		}
+	default:
+		return nil, fmt.Errorf("geo_shape: shape or indexed shape must be set")
+	}
+	if q.relation != "" {
+		field["relation"] = q.relation
+	}
+	params[q.name] = field
+
+	if q.ignoreUnmapped != nil {
+		params["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.queryName != "" {
+		params["_name"] = q.queryName
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
@@ -0,0 +1,172 @@
+package esbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// grokPatterns is the subset of the Elasticsearch grok patterns available
+// to the local ingest simulator. Patterns relying on lookarounds are
+// rewritten without them, since Go regular expressions do not support them.
+var grokPatterns = map[string]string{
+	"USERNAME":          `[a-zA-Z0-9._-]+`,
+	"USER":              `%{USERNAME}`,
+	"EMAILLOCALPART":    `[a-zA-Z][a-zA-Z0-9_.+-=:]+`,
+	"EMAILADDRESS":      `%{EMAILLOCALPART}@%{HOSTNAME}`,
+	"INT":               `(?:[+-]?(?:[0-9]+))`,
+	"BASE10NUM":         `(?:[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+))`,
+	"NUMBER":            `(?:%{BASE10NUM})`,
+	"BASE16NUM":         `(?:[+-]?(?:0x)?(?:[0-9A-Fa-f]+))`,
+	"POSINT":            `\b(?:[1-9][0-9]*)\b`,
+	"NONNEGINT":         `\b(?:[0-9]+)\b`,
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"QUOTEDSTRING":      `(?:"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|` + "`(?:[^`\\\\]|\\\\.)*`)",
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"IPV4":              `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`,
+	"IPV6":              `(?:(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}|(?:[0-9A-Fa-f]{1,4}:){1,7}:|(?:[0-9A-Fa-f]{1,4}:){1,6}:[0-9A-Fa-f]{1,4}|::(?:[0-9A-Fa-f]{1,4}:){0,5}[0-9A-Fa-f]{1,4}|::)`,
+	"IP":                `(?:%{IPV6}|%{IPV4})`,
+	"HOSTNAME":          `\b(?:[0-9A-Za-z][0-9A-Za-z-]{0,62})(?:\.(?:[0-9A-Za-z][0-9A-Za-z-]{0,62}))*\.?\b`,
+	"IPORHOST":          `(?:%{IP}|%{HOSTNAME})`,
+	"HOSTPORT":          `%{IPORHOST}:%{POSINT}`,
+	"URIPATH":           `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"URIPARAM":          `\?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*`,
+	"URIPATHPARAM":      `%{URIPATH}(?:%{URIPARAM})?`,
+	"MONTH":             `\b(?:[Jj]an(?:uary)?|[Ff]eb(?:ruary)?|[Mm]ar(?:ch)?|[Aa]pr(?:il)?|[Mm]ay|[Jj]un(?:e)?|[Jj]ul(?:y)?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo]ct(?:ober)?|[Nn]ov(?:ember)?|[Dd]ec(?:ember)?)\b`,
+	"MONTHNUM":          `(?:0?[1-9]|1[0-2])`,
+	"MONTHDAY":          `(?:(?:0[1-9])|(?:[12][0-9])|(?:3[01])|[1-9])`,
+	"DAY":               `(?:Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?)`,
+	"YEAR":              `(?:\d\d){1,2}`,
+	"HOUR":              `(?:2[0123]|[01]?[0-9])`,
+	"MINUTE":            `(?:[0-5][0-9])`,
+	"SECOND":            `(?:(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?)`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"ISO8601_TIMEZONE":  `(?:Z|[+-]%{HOUR}(?::?%{MINUTE}))`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?`,
+	"DATE_US":           `%{MONTHNUM}[/-]%{MONTHDAY}[/-]%{YEAR}`,
+	"DATE_EU":           `%{MONTHDAY}[./-]%{MONTHNUM}[./-]%{YEAR}`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+	"LOGLEVEL":          `(?:[Aa]lert|ALERT|[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo|INFO|[Ww]arn?(?:ing)?|WARN?(?:ING)?|[Ee]rr?(?:or)?|ERR?(?:OR)?|[Cc]rit?(?:ical)?|CRIT?(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|EMERG(?:ENCY)?|[Ee]merg(?:ency)?)`,
+	"HTTPDUSER":         `(?:%{EMAILADDRESS}|%{USER})`,
+	"COMMONAPACHELOG":   `%{IPORHOST:clientip} %{HTTPDUSER:ident} %{USER:auth} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:verb} %{NOTSPACE:request}(?: HTTP/%{NUMBER:httpversion})?|%{DATA:rawrequest})" %{NUMBER:response} (?:%{NUMBER:bytes}|-)`,
+	"COMBINEDAPACHELOG": `%{COMMONAPACHELOG} %{QUOTEDSTRING:referrer} %{QUOTEDSTRING:agent}`,
+}
+
+var (
+	grokReferenceRe = regexp.MustCompile(`%\{(\w+)(?::([\w.@\[\]]+))?(?::(\w+))?\}`)
+	grokNamedRe     = regexp.MustCompile(`\(\?<([\w.@\[\]]+)>`)
+)
+
+// grokCapture is a named field of a compiled grok expression.
+type grokCapture struct {
+	field     string
+	valueType string
+}
+
+// grokExpression is a grok pattern compiled to a Go regular expression.
+type grokExpression struct {
+	re       *regexp.Regexp
+	captures map[string]grokCapture
+}
+
+// compileGrok expands the %{NAME:field:type} references and (?<field>...)
+// groups of pattern using the built-in patterns and definitions.
+func compileGrok(pattern string, definitions map[string]string) (*grokExpression, error) {
+	g := &grokExpression{captures: make(map[string]grokCapture)}
+	expanded, err := g.expand(pattern, definitions, 0)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("grok: invalid pattern %q: %v", pattern, err)
+	}
+	g.re = re
+	return g, nil
+}
+
+func (g *grokExpression) capture(field, valueType string) string {
+	name := "g" + strconv.Itoa(len(g.captures))
+	g.captures[name] = grokCapture{field: field, valueType: valueType}
+	return "(?P<" + name + ">"
+}
+
+func (g *grokExpression) expand(pattern string, definitions map[string]string, depth int) (string, error) {
+	if depth > 20 {
+		return "", fmt.Errorf("grok: patterns nested too deeply, probably circular")
+	}
+	pattern = grokNamedRe.ReplaceAllStringFunc(pattern, func(group string) string {
+		return g.capture(grokNamedRe.FindStringSubmatch(group)[1], "")
+	})
+	var sb strings.Builder
+	last := 0
+	for _, loc := range grokReferenceRe.FindAllStringSubmatchIndex(pattern, -1) {
+		sb.WriteString(pattern[last:loc[0]])
+		last = loc[1]
+		name := pattern[loc[2]:loc[3]]
+		definition, ok := definitions[name]
+		if !ok {
+			definition, ok = grokPatterns[name]
+		}
+		if !ok {
+			return "", fmt.Errorf("grok: unable to find pattern [%s]", name)
+		}
+		inner, err := g.expand(definition, definitions, depth+1)
+		if err != nil {
+			return "", err
+		}
+		if loc[4] < 0 {
+			sb.WriteString("(?:" + inner + ")")
+			continue
+		}
+		valueType := ""
+		if loc[6] >= 0 {
+			valueType = pattern[loc[6]:loc[7]]
+			if valueType != "int" && valueType != "float" {
+				return "", fmt.Errorf("grok: unsupported type %s of field %s", valueType, pattern[loc[4]:loc[5]])
+			}
+		}
+		sb.WriteString(g.capture(pattern[loc[4]:loc[5]], valueType) + inner + ")")
+	}
+	sb.WriteString(pattern[last:])
+	return sb.String(), nil
+}
+
+// match returns the captured fields of the first match in text.
+func (g *grokExpression) match(text string) (map[string]interface{}, bool, error) {
+	loc := g.re.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return nil, false, nil
+	}
+	fields := make(map[string]interface{})
+	for i, name := range g.re.SubexpNames() {
+		capture, ok := g.captures[name]
+		if !ok || loc[2*i] < 0 {
+			continue
+		}
+		value := text[loc[2*i]:loc[2*i+1]]
+		switch capture.valueType {
+		case "int":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, false, fmt.Errorf("grok: can not convert [%s] of field %s to int", value, capture.field)
+			}
+			fields[capture.field] = n
+		case "float":
+			f, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				return nil, false, fmt.Errorf("grok: can not convert [%s] of field %s to float", value, capture.field)
+			}
+			fields[capture.field] = f
+		default:
+			fields[capture.field] = value
+		}
+	}
+	return fields, true, nil
+}
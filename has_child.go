@@ -0,0 +1,103 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-has-child-query.html
+type hasChildQuery struct {
+	childType      string
+	queryItem      query
+	minChildren    *int
+	maxChildren    *int
+	scoreMode      string
+	ignoreUnmapped *bool
+	innerHits      *innerHits
+	boost          *float64
+}
+
+// NewHasChildQuery creates and initializes a new hasChildQuery.
+func NewHasChildQuery(childType string, query query) *hasChildQuery {
+	return &hasChildQuery{childType: childType, queryItem: query}
+}
+
+// MinChildren is the minimum number of matching child documents required
+// for a parent document to match.
+func (q *hasChildQuery) MinChildren(minChildren int) *hasChildQuery {
+	q.minChildren = &minChildren
+	return q
+}
+
+// MaxChildren is the maximum number of matching child documents allowed
+// for a parent document to match.
+func (q *hasChildQuery) MaxChildren(maxChildren int) *hasChildQuery {
+	q.maxChildren = &maxChildren
+	return q
+}
+
+// ScoreMode specifies how scores of matching child documents affect the
+// parent document score: none (default), avg, max, min or sum.
+func (q *hasChildQuery) ScoreMode(scoreMode string) *hasChildQuery {
+	q.scoreMode = scoreMode
+	return q
+}
+
+// IgnoreUnmapped ignores an unmapped type instead of returning an error.
+func (q *hasChildQuery) IgnoreUnmapped(ignoreUnmapped bool) *hasChildQuery {
+	q.ignoreUnmapped = &ignoreUnmapped
+	return q
+}
+
+// InnerHits returns the matching child documents with each hit.
+func (q *hasChildQuery) InnerHits(innerHits *innerHits) *hasChildQuery {
+	q.innerHits = innerHits
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *hasChildQuery) Boost(boost float64) *hasChildQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the has_child query.
+func (q *hasChildQuery) Build() (interface{}, error) {
+	if q.childType == "" || q.queryItem == nil {
+		return nil, fmt.Errorf("has_child: type and query must be set")
+	}
+	if q.minChildren != nil && q.maxChildren != nil && *q.minChildren > *q.maxChildren {
+		return nil, fmt.Errorf("has_child: min_children can not be greater than max_children")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["has_child"] = params
+
+	params["type"] = q.childType
+	src, err := q.queryItem.Build()
+	if err != nil {
+		return nil, err
+	}
+	params["query"] = src
+	if q.minChildren != nil {
+		params["min_children"] = *q.minChildren
+	}
+	if q.maxChildren != nil {
+		params["max_children"] = *q.maxChildren
+	}
+	if q.scoreMode != "" {
+		params["score_mode"] = q.scoreMode
+	}
+	if q.ignoreUnmapped != nil {
+		params["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.innerHits != nil {
+		src, err := q.innerHits.Build()
+		if err != nil {
+			return nil, err
+		}
+		params["inner_hits"] = src
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
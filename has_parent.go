@@ -0,0 +1,78 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-has-parent-query.html
+type hasParentQuery struct {
+	parentType     string
+	queryItem      query
+	score          *bool
+	ignoreUnmapped *bool
+	innerHits      *innerHits
+	boost          *float64
+}
+
+// NewHasParentQuery creates and initializes a new hasParentQuery.
+func NewHasParentQuery(parentType string, query query) *hasParentQuery {
+	return &hasParentQuery{parentType: parentType, queryItem: query}
+}
+
+// Score indicates whether the relevance score of a matching parent
+// document is aggregated into its child documents.
+func (q *hasParentQuery) Score(score bool) *hasParentQuery {
+	q.score = &score
+	return q
+}
+
+// IgnoreUnmapped ignores an unmapped parent_type instead of returning an error.
+func (q *hasParentQuery) IgnoreUnmapped(ignoreUnmapped bool) *hasParentQuery {
+	q.ignoreUnmapped = &ignoreUnmapped
+	return q
+}
+
+// InnerHits returns the matching parent document with each hit.
+func (q *hasParentQuery) InnerHits(innerHits *innerHits) *hasParentQuery {
+	q.innerHits = innerHits
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *hasParentQuery) Boost(boost float64) *hasParentQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the has_parent query.
+func (q *hasParentQuery) Build() (interface{}, error) {
+	if q.parentType == "" || q.queryItem == nil {
+		return nil, fmt.Errorf("has_parent: parent_type and query must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["has_parent"] = params
+
+	params["parent_type"] = q.parentType
+	src, err := q.queryItem.Build()
+	if err != nil {
+		return nil, err
+	}
+	params["query"] = src
+	if q.score != nil {
+		params["score"] = *q.score
+	}
+	if q.ignoreUnmapped != nil {
+		params["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.innerHits != nil {
+		src, err := q.innerHits.Build()
+		if err != nil {
+			return nil, err
+		}
+		params["inner_hits"] = src
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
@@ -0,0 +1,197 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/highlighting.html
+type highlight struct {
+	highlighterType   string
+	fragmentSize      *int
+	numberOfFragments *int
+	preTags           []string
+	postTags          []string
+	encoder           string
+	highlightQuery    query
+	requireFieldMatch *bool
+	boundaryScanner   string
+	matchedFields     []string
+	noMatchSize       *int
+	order             string
+	fields            []*highlightField
+}
+
+type highlightField struct {
+	name    string
+	options *highlight
+}
+
+// NewHighlight creates and initializes a new highlight. The same builder
+// is used for the global settings and for per-field settings.
+func NewHighlight() *highlight {
+	return &highlight{
+		preTags:       make([]string, 0),
+		postTags:      make([]string, 0),
+		matchedFields: make([]string, 0),
+		fields:        make([]*highlightField, 0),
+	}
+}
+
+// Field adds a field to highlight. options may be nil to use the global settings.
+func (h *highlight) Field(name string, options *highlight) *highlight {
+	h.fields = append(h.fields, &highlightField{name: name, options: options})
+	return h
+}
+
+// Type is the highlighter to use: unified (default), plain or fvh.
+func (h *highlight) Type(highlighterType string) *highlight {
+	h.highlighterType = highlighterType
+	return h
+}
+
+// FragmentSize is the size of the highlighted fragment in characters. Defaults to 100.
+func (h *highlight) FragmentSize(fragmentSize int) *highlight {
+	h.fragmentSize = &fragmentSize
+	return h
+}
+
+// NumberOfFragments is the maximum number of fragments to return. If it
+// is 0, the entire field contents are highlighted. Defaults to 5.
+func (h *highlight) NumberOfFragments(numberOfFragments int) *highlight {
+	h.numberOfFragments = &numberOfFragments
+	return h
+}
+
+// PreTags are the tags inserted before highlighted text.
+func (h *highlight) PreTags(tags ...string) *highlight {
+	h.preTags = append(h.preTags, tags...)
+	return h
+}
+
+// PostTags are the tags inserted after highlighted text.
+func (h *highlight) PostTags(tags ...string) *highlight {
+	h.postTags = append(h.postTags, tags...)
+	return h
+}
+
+// Encoder is default or html.
+func (h *highlight) Encoder(encoder string) *highlight {
+	h.encoder = encoder
+	return h
+}
+
+// HighlightQuery highlights matches of a query other than the search query.
+func (h *highlight) HighlightQuery(query query) *highlight {
+	h.highlightQuery = query
+	return h
+}
+
+// RequireFieldMatch only highlights fields that contain a query match. Defaults to true.
+func (h *highlight) RequireFieldMatch(requireFieldMatch bool) *highlight {
+	h.requireFieldMatch = &requireFieldMatch
+	return h
+}
+
+// BoundaryScanner specifies how to break fragments: chars, sentence or word.
+func (h *highlight) BoundaryScanner(boundaryScanner string) *highlight {
+	h.boundaryScanner = boundaryScanner
+	return h
+}
+
+// MatchedFields combines matches on multiple fields, fvh only.
+func (h *highlight) MatchedFields(fields ...string) *highlight {
+	h.matchedFields = append(h.matchedFields, fields...)
+	return h
+}
+
+// NoMatchSize is the amount of text returned from the beginning of the
+// field if there are no matching fragments.
+func (h *highlight) NoMatchSize(noMatchSize int) *highlight {
+	h.noMatchSize = &noMatchSize
+	return h
+}
+
+// Order sorts highlighted fragments by score when set to "score".
+func (h *highlight) Order(order string) *highlight {
+	h.order = order
+	return h
+}
+
+// Build returns the map for the highlight section.
+func (h *highlight) Build() (interface{}, error) {
+	source, err := h.buildOptions()
+	if err != nil {
+		return nil, err
+	}
+	if len(h.fields) > 0 {
+		fields := make(map[string]interface{})
+		for _, field := range h.fields {
+			if field.name == "" {
+				return nil, fmt.Errorf("highlight: field name can not be empty")
+			}
+			if field.options == nil {
+				fields[field.name] = map[string]interface{}{}
+				continue
+			}
+			if len(field.options.fields) > 0 {
+				return nil, fmt.Errorf("highlight: field %s can not have sub fields", field.name)
+			}
+			src, err := field.options.buildOptions()
+			if err != nil {
+				return nil, err
+			}
+			fields[field.name] = src
+		}
+		source["fields"] = fields
+	}
+	return source, nil
+}
+
+func (h *highlight) buildOptions() (map[string]interface{}, error) {
+	source := make(map[string]interface{})
+	if h.highlighterType != "" {
+		switch h.highlighterType {
+		case "unified", "plain", "fvh":
+		default:
+			return nil, fmt.Errorf("highlight: invalid type %q", h.highlighterType)
+		}
+		source["type"] = h.highlighterType
+	}
+	if h.fragmentSize != nil {
+		source["fragment_size"] = *h.fragmentSize
+	}
+	if h.numberOfFragments != nil {
+		source["number_of_fragments"] = *h.numberOfFragments
+	}
+	if len(h.preTags) > 0 {
+		source["pre_tags"] = h.preTags
+	}
+	if len(h.postTags) > 0 {
+		source["post_tags"] = h.postTags
+	}
+	if h.encoder != "" {
+		source["encoder"] = h.encoder
+	}
+	if h.highlightQuery != nil {
+		src, err := h.highlightQuery.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["highlight_query"] = src
+	}
+	if h.requireFieldMatch != nil {
+		source["require_field_match"] = *h.requireFieldMatch
+	}
+	if h.boundaryScanner != "" {
+		source["boundary_scanner"] = h.boundaryScanner
+	}
+	if len(h.matchedFields) > 0 {
+		source["matched_fields"] = h.matchedFields
+	}
+	if h.noMatchSize != nil {
+		source["no_match_size"] = *h.noMatchSize
+	}
+	if h.order != "" {
+		source["order"] = h.order
+	}
+	return source, nil
+}
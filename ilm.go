@@ -0,0 +1,547 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ilmPhases lists the ILM phases in execution order with the actions
+// each one allows.
+var ilmPhases = []struct {
+	name    string
+	actions []string
+}{
+	{"hot", []string{"set_priority", "unfollow", "rollover", "readonly", "shrink", "forcemerge", "searchable_snapshot"}},
+	{"warm", []string{"set_priority", "unfollow", "readonly", "allocate", "migrate", "shrink", "forcemerge"}},
+	{"cold", []string{"set_priority", "unfollow", "allocate", "migrate", "freeze", "searchable_snapshot"}},
+	{"delete", []string{"wait_for_snapshot", "delete"}},
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/ilm-put-lifecycle.html
+type ilmPolicy struct {
+	name   string
+	phases []*ilmPhase
+	meta   map[string]interface{}
+}
+
+// NewIlmPolicy creates and initializes a new ilmPolicy.
+func NewIlmPolicy(name string, phases ...*ilmPhase) *ilmPolicy {
+	return &ilmPolicy{
+		name:   name,
+		phases: append(make([]*ilmPhase, 0), phases...),
+	}
+}
+
+// Phase adds phases to the policy.
+func (p *ilmPolicy) Phase(phases ...*ilmPhase) *ilmPolicy {
+	p.phases = append(p.phases, phases...)
+	return p
+}
+
+// Meta sets custom metadata of the policy.
+func (p *ilmPolicy) Meta(key string, value interface{}) *ilmPolicy {
+	if p.meta == nil {
+		p.meta = make(map[string]interface{})
+	}
+	p.meta[key] = value
+	return p
+}
+
+// Build returns the body of the PUT _ilm/policy request. Phases must be
+// known, unique and have non-decreasing min_age in execution order.
+func (p *ilmPolicy) Build() (interface{}, error) {
+	if p.name == "" {
+		return nil, fmt.Errorf("ilm policy: name must be set")
+	}
+	if len(p.phases) == 0 {
+		return nil, fmt.Errorf("ilm policy %s: at least one phase must be added", p.name)
+	}
+	byName := make(map[string]*ilmPhase, len(p.phases))
+	for _, phase := range p.phases {
+		if _, ok := byName[phase.name]; ok {
+			return nil, fmt.Errorf("ilm policy %s: duplicate phase %s", p.name, phase.name)
+		}
+		byName[phase.name] = phase
+	}
+	phases := make(map[string]interface{}, len(p.phases))
+	var previous *ilmPhase
+	var previousAge int64
+	for _, known := range ilmPhases {
+		phase, ok := byName[known.name]
+		if !ok {
+			continue
+		}
+		delete(byName, known.name)
+		age, err := phase.age()
+		if err != nil {
+			return nil, fmt.Errorf("ilm policy %s: %v", p.name, err)
+		}
+		if previous != nil && age < previousAge {
+			return nil, fmt.Errorf("ilm policy %s: min_age of phase %s (%s) is before min_age of phase %s (%s)",
+				p.name, phase.name, phase.minAge, previous.name, previous.minAge)
+		}
+		src, err := phase.build(known.actions)
+		if err != nil {
+			return nil, fmt.Errorf("ilm policy %s: %v", p.name, err)
+		}
+		if phase.name == "hot" && phase.hasAction("rollover") == "" {
+			if action := phase.hasAction("shrink", "forcemerge", "searchable_snapshot"); action != "" {
+				return nil, fmt.Errorf("ilm policy %s: %s in the hot phase requires rollover", p.name, action)
+			}
+		}
+		phases[phase.name] = src
+		previous, previousAge = phase, age
+	}
+	if len(byName) > 0 {
+		unknown := make([]string, 0, len(byName))
+		for name := range byName {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("ilm policy %s: unknown phase %s", p.name, strings.Join(unknown, ", "))
+	}
+	policy := map[string]interface{}{
+		"phases": phases,
+	}
+	if len(p.meta) > 0 {
+		policy["_meta"] = p.meta
+	}
+	return map[string]interface{}{
+		"policy": policy,
+	}, nil
+}
+
+// Do sends the PUT _ilm/policy request.
+func (p *ilmPolicy) Do(ctx context.Context, t Transport) error {
+	src, err := p.Build()
+	if err != nil {
+		return err
+	}
+	_, err = perform(ctx, t, "PUT", "_ilm/policy/"+url.PathEscape(p.name), src)
+	return err
+}
+
+// ilmPhase is one phase of an ilmPolicy.
+type ilmPhase struct {
+	name    string
+	minAge  string
+	actions []ilmAction
+}
+
+// ilmAction is an action run in an ilmPhase.
+type ilmAction interface {
+	query
+	ilmActionName() string
+}
+
+// NewIlmPhase creates a phase named hot, warm, cold or delete.
+func NewIlmPhase(name string, actions ...ilmAction) *ilmPhase {
+	return &ilmPhase{
+		name:    name,
+		actions: append(make([]ilmAction, 0), actions...),
+	}
+}
+
+// MinAge sets the age of the index, since creation or rollover, at which
+// the phase starts, e.g. "30d".
+func (p *ilmPhase) MinAge(minAge string) *ilmPhase {
+	p.minAge = minAge
+	return p
+}
+
+// Action adds actions to the phase.
+func (p *ilmPhase) Action(actions ...ilmAction) *ilmPhase {
+	p.actions = append(p.actions, actions...)
+	return p
+}
+
+func (p *ilmPhase) age() (int64, error) {
+	if p.minAge == "" {
+		return 0, nil
+	}
+	age, err := parseTimeValue(p.minAge)
+	if err != nil {
+		return 0, fmt.Errorf("phase %s: min_age: %v", p.name, err)
+	}
+	return age, nil
+}
+
+// hasAction returns the first of names that the phase contains.
+func (p *ilmPhase) hasAction(names ...string) string {
+	for _, action := range p.actions {
+		if containsString(names, action.ilmActionName()) {
+			return action.ilmActionName()
+		}
+	}
+	return ""
+}
+
+func (p *ilmPhase) build(allowed []string) (map[string]interface{}, error) {
+	actions := make(map[string]interface{}, len(p.actions))
+	for _, action := range p.actions {
+		name := action.ilmActionName()
+		if !containsString(allowed, name) {
+			return nil, fmt.Errorf("phase %s: action %s is not allowed", p.name, name)
+		}
+		if _, ok := actions[name]; ok {
+			return nil, fmt.Errorf("phase %s: duplicate action %s", p.name, name)
+		}
+		src, err := action.Build()
+		if err != nil {
+			return nil, fmt.Errorf("phase %s: %v", p.name, err)
+		}
+		actions[name] = src
+	}
+	source := map[string]interface{}{
+		"actions": actions,
+	}
+	if p.minAge != "" {
+		source["min_age"] = p.minAge
+	}
+	return source, nil
+}
+
+// ilmRolloverAction rolls the index over when a condition is met.
+type ilmRolloverAction struct {
+	rolloverConditions
+}
+
+// NewIlmRolloverAction creates and initializes a new rollover action.
+func NewIlmRolloverAction() *ilmRolloverAction {
+	return &ilmRolloverAction{}
+}
+
+func (a *ilmRolloverAction) ilmActionName() string { return "rollover" }
+
+// MaxAge rolls over when the index is older than maxAge.
+func (a *ilmRolloverAction) MaxAge(maxAge string) *ilmRolloverAction {
+	a.maxAge = maxAge
+	return a
+}
+
+// MaxDocs rolls over when the index holds at least maxDocs documents.
+func (a *ilmRolloverAction) MaxDocs(maxDocs int64) *ilmRolloverAction {
+	a.maxDocs = &maxDocs
+	return a
+}
+
+// MaxSize rolls over when the primary shards reach maxSize.
+func (a *ilmRolloverAction) MaxSize(maxSize string) *ilmRolloverAction {
+	a.maxSize = maxSize
+	return a
+}
+
+// MaxPrimaryShardSize rolls over when the largest primary shard reaches
+// maxSize.
+func (a *ilmRolloverAction) MaxPrimaryShardSize(maxSize string) *ilmRolloverAction {
+	a.maxPrimaryShardSize = maxSize
+	return a
+}
+
+func (a *ilmRolloverAction) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	if err := a.build(source); err != nil {
+		return nil, fmt.Errorf("rollover: %v", err)
+	}
+	if len(source) == 0 {
+		return nil, fmt.Errorf("rollover: at least one condition must be set")
+	}
+	return source, nil
+}
+
+// ilmShrinkAction shrinks the index to fewer primary shards.
+type ilmShrinkAction struct {
+	numberOfShards int
+}
+
+// NewIlmShrinkAction creates a shrink action to numberOfShards shards.
+func NewIlmShrinkAction(numberOfShards int) *ilmShrinkAction {
+	return &ilmShrinkAction{numberOfShards: numberOfShards}
+}
+
+func (a *ilmShrinkAction) ilmActionName() string { return "shrink" }
+
+func (a *ilmShrinkAction) Build() (interface{}, error) {
+	if a.numberOfShards < 1 {
+		return nil, fmt.Errorf("shrink: number_of_shards must be at least 1")
+	}
+	return map[string]interface{}{
+		"number_of_shards": a.numberOfShards,
+	}, nil
+}
+
+// ilmForcemergeAction merges the index into at most maxNumSegments segments.
+type ilmForcemergeAction struct {
+	maxNumSegments int
+	indexCodec     string
+}
+
+// NewIlmForcemergeAction creates and initializes a new forcemerge action.
+func NewIlmForcemergeAction(maxNumSegments int) *ilmForcemergeAction {
+	return &ilmForcemergeAction{maxNumSegments: maxNumSegments}
+}
+
+func (a *ilmForcemergeAction) ilmActionName() string { return "forcemerge" }
+
+// IndexCodec sets the codec used to compress the merged segments,
+// e.g. "best_compression".
+func (a *ilmForcemergeAction) IndexCodec(codec string) *ilmForcemergeAction {
+	a.indexCodec = codec
+	return a
+}
+
+func (a *ilmForcemergeAction) Build() (interface{}, error) {
+	if a.maxNumSegments < 1 {
+		return nil, fmt.Errorf("forcemerge: max_num_segments must be at least 1")
+	}
+	source := map[string]interface{}{
+		"max_num_segments": a.maxNumSegments,
+	}
+	if a.indexCodec != "" {
+		source["index_codec"] = a.indexCodec
+	}
+	return source, nil
+}
+
+// ilmAllocateAction changes the replicas and shard allocation filters.
+type ilmAllocateAction struct {
+	numberOfReplicas *int
+	include          map[string]string
+	exclude          map[string]string
+	require          map[string]string
+}
+
+// NewIlmAllocateAction creates and initializes a new allocate action.
+func NewIlmAllocateAction() *ilmAllocateAction {
+	return &ilmAllocateAction{
+		include: make(map[string]string),
+		exclude: make(map[string]string),
+		require: make(map[string]string),
+	}
+}
+
+func (a *ilmAllocateAction) ilmActionName() string { return "allocate" }
+
+// NumberOfReplicas sets the number of replicas.
+func (a *ilmAllocateAction) NumberOfReplicas(replicas int) *ilmAllocateAction {
+	a.numberOfReplicas = &replicas
+	return a
+}
+
+// Include allocates shards to nodes with at least one of the values of attribute.
+func (a *ilmAllocateAction) Include(attribute, values string) *ilmAllocateAction {
+	a.include[attribute] = values
+	return a
+}
+
+// Exclude allocates shards to nodes with none of the values of attribute.
+func (a *ilmAllocateAction) Exclude(attribute, values string) *ilmAllocateAction {
+	a.exclude[attribute] = values
+	return a
+}
+
+// Require allocates shards to nodes with all the values of attribute.
+func (a *ilmAllocateAction) Require(attribute, values string) *ilmAllocateAction {
+	a.require[attribute] = values
+	return a
+}
+
+func (a *ilmAllocateAction) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	if a.numberOfReplicas != nil {
+		if *a.numberOfReplicas < 0 {
+			return nil, fmt.Errorf("allocate: number_of_replicas can not be negative")
+		}
+		source["number_of_replicas"] = *a.numberOfReplicas
+	}
+	if len(a.include) > 0 {
+		source["include"] = a.include
+	}
+	if len(a.exclude) > 0 {
+		source["exclude"] = a.exclude
+	}
+	if len(a.require) > 0 {
+		source["require"] = a.require
+	}
+	if len(source) == 0 {
+		return nil, fmt.Errorf("allocate: number_of_replicas, include, exclude or require must be set")
+	}
+	return source, nil
+}
+
+// ilmSetPriorityAction sets the recovery priority of the index.
+type ilmSetPriorityAction struct {
+	priority int
+}
+
+// NewIlmSetPriorityAction creates and initializes a new set_priority action.
+func NewIlmSetPriorityAction(priority int) *ilmSetPriorityAction {
+	return &ilmSetPriorityAction{priority: priority}
+}
+
+func (a *ilmSetPriorityAction) ilmActionName() string { return "set_priority" }
+
+func (a *ilmSetPriorityAction) Build() (interface{}, error) {
+	if a.priority < 0 {
+		return nil, fmt.Errorf("set_priority: priority can not be negative")
+	}
+	return map[string]interface{}{
+		"priority": a.priority,
+	}, nil
+}
+
+// ilmSearchableSnapshotAction mounts a snapshot of the index.
+type ilmSearchableSnapshotAction struct {
+	repository      string
+	forceMergeIndex *bool
+}
+
+// NewIlmSearchableSnapshotAction creates a searchable_snapshot action
+// storing the snapshot in repository.
+func NewIlmSearchableSnapshotAction(repository string) *ilmSearchableSnapshotAction {
+	return &ilmSearchableSnapshotAction{repository: repository}
+}
+
+func (a *ilmSearchableSnapshotAction) ilmActionName() string { return "searchable_snapshot" }
+
+// ForceMergeIndex force merges the index to one segment before the snapshot.
+func (a *ilmSearchableSnapshotAction) ForceMergeIndex(forceMerge bool) *ilmSearchableSnapshotAction {
+	a.forceMergeIndex = &forceMerge
+	return a
+}
+
+func (a *ilmSearchableSnapshotAction) Build() (interface{}, error) {
+	if a.repository == "" {
+		return nil, fmt.Errorf("searchable_snapshot: snapshot_repository must be set")
+	}
+	source := map[string]interface{}{
+		"snapshot_repository": a.repository,
+	}
+	if a.forceMergeIndex != nil {
+		source["force_merge_index"] = *a.forceMergeIndex
+	}
+	return source, nil
+}
+
+// ilmDeleteAction deletes the index.
+type ilmDeleteAction struct {
+	deleteSearchableSnapshot *bool
+}
+
+// NewIlmDeleteAction creates and initializes a new delete action.
+func NewIlmDeleteAction() *ilmDeleteAction {
+	return &ilmDeleteAction{}
+}
+
+func (a *ilmDeleteAction) ilmActionName() string { return "delete" }
+
+// DeleteSearchableSnapshot also deletes the snapshot of a searchable
+// snapshot index. Defaults to true.
+func (a *ilmDeleteAction) DeleteSearchableSnapshot(deleteSnapshot bool) *ilmDeleteAction {
+	a.deleteSearchableSnapshot = &deleteSnapshot
+	return a
+}
+
+func (a *ilmDeleteAction) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	if a.deleteSearchableSnapshot != nil {
+		source["delete_searchable_snapshot"] = *a.deleteSearchableSnapshot
+	}
+	return source, nil
+}
+
+// ilmReadonlyAction makes the index read-only.
+type ilmReadonlyAction struct{}
+
+// NewIlmReadonlyAction creates and initializes a new readonly action.
+func NewIlmReadonlyAction() *ilmReadonlyAction {
+	return &ilmReadonlyAction{}
+}
+
+func (a *ilmReadonlyAction) ilmActionName() string { return "readonly" }
+
+func (a *ilmReadonlyAction) Build() (interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+// ilmUnfollowAction turns a follower index of cross-cluster replication
+// into a regular index.
+type ilmUnfollowAction struct{}
+
+// NewIlmUnfollowAction creates and initializes a new unfollow action.
+func NewIlmUnfollowAction() *ilmUnfollowAction {
+	return &ilmUnfollowAction{}
+}
+
+func (a *ilmUnfollowAction) ilmActionName() string { return "unfollow" }
+
+func (a *ilmUnfollowAction) Build() (interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+// ilmMigrateAction moves the index to the data tier of the phase.
+type ilmMigrateAction struct {
+	enabled *bool
+}
+
+// NewIlmMigrateAction creates and initializes a new migrate action.
+func NewIlmMigrateAction() *ilmMigrateAction {
+	return &ilmMigrateAction{}
+}
+
+func (a *ilmMigrateAction) ilmActionName() string { return "migrate" }
+
+// Enabled set to false disables the automatic migration of the phase.
+func (a *ilmMigrateAction) Enabled(enabled bool) *ilmMigrateAction {
+	a.enabled = &enabled
+	return a
+}
+
+func (a *ilmMigrateAction) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	if a.enabled != nil {
+		source["enabled"] = *a.enabled
+	}
+	return source, nil
+}
+
+// ilmFreezeAction freezes the index to minimize its memory footprint.
+type ilmFreezeAction struct{}
+
+// NewIlmFreezeAction creates and initializes a new freeze action.
+func NewIlmFreezeAction() *ilmFreezeAction {
+	return &ilmFreezeAction{}
+}
+
+func (a *ilmFreezeAction) ilmActionName() string { return "freeze" }
+
+func (a *ilmFreezeAction) Build() (interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+// ilmWaitForSnapshotAction waits for a snapshot lifecycle policy to take
+// a snapshot before the index is deleted.
+type ilmWaitForSnapshotAction struct {
+	policy string
+}
+
+// NewIlmWaitForSnapshotAction creates a wait_for_snapshot action waiting
+// for the snapshot lifecycle policy named policy.
+func NewIlmWaitForSnapshotAction(policy string) *ilmWaitForSnapshotAction {
+	return &ilmWaitForSnapshotAction{policy: policy}
+}
+
+func (a *ilmWaitForSnapshotAction) ilmActionName() string { return "wait_for_snapshot" }
+
+func (a *ilmWaitForSnapshotAction) Build() (interface{}, error) {
+	if a.policy == "" {
+		return nil, fmt.Errorf("wait_for_snapshot: policy must be set")
+	}
+	return map[string]interface{}{
+		"policy": a.policy,
+	}, nil
+}
@@ -0,0 +1,240 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// templateBody is the template section shared by index and component
+// templates.
+type templateBody struct {
+	settings *indexSettings
+	mappings *mapping
+	aliases  []*alias
+	version  *int64
+	meta     map[string]interface{}
+}
+
+func (b *templateBody) build(source map[string]interface{}) error {
+	template := make(map[string]interface{})
+	if b.settings != nil {
+		src, err := b.settings.Build()
+		if err != nil {
+			return err
+		}
+		template["settings"] = src
+	}
+	if b.mappings != nil {
+		src, err := b.mappings.Build()
+		if err != nil {
+			return err
+		}
+		template["mappings"] = src
+	}
+	if len(b.aliases) > 0 {
+		src, err := buildAliases(b.aliases)
+		if err != nil {
+			return err
+		}
+		template["aliases"] = src
+	}
+	if len(template) > 0 {
+		source["template"] = template
+	}
+	if b.version != nil {
+		source["version"] = *b.version
+	}
+	if len(b.meta) > 0 {
+		source["_meta"] = b.meta
+	}
+	return nil
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/index-templates.html
+type indexTemplate struct {
+	templateBody
+	name          string
+	indexPatterns []string
+	priority      *int
+	composedOf    []string
+	dataStream    *bool
+}
+
+// NewIndexTemplate creates and initializes a new composable indexTemplate.
+func NewIndexTemplate(name string) *indexTemplate {
+	return &indexTemplate{
+		name:          name,
+		indexPatterns: make([]string, 0),
+		composedOf:    make([]string, 0),
+	}
+}
+
+// IndexPatterns adds the patterns of index names the template applies to.
+func (t *indexTemplate) IndexPatterns(patterns ...string) *indexTemplate {
+	t.indexPatterns = append(t.indexPatterns, patterns...)
+	return t
+}
+
+// Priority decides which template applies when several match, the
+// highest wins.
+func (t *indexTemplate) Priority(priority int) *indexTemplate {
+	t.priority = &priority
+	return t
+}
+
+// ComposedOf adds component templates, merged in order.
+func (t *indexTemplate) ComposedOf(names ...string) *indexTemplate {
+	t.composedOf = append(t.composedOf, names...)
+	return t
+}
+
+// Settings sets the index settings of the template.
+func (t *indexTemplate) Settings(settings *indexSettings) *indexTemplate {
+	t.settings = settings
+	return t
+}
+
+// Mappings sets the mapping of the template.
+func (t *indexTemplate) Mappings(mappings *mapping) *indexTemplate {
+	t.mappings = mappings
+	return t
+}
+
+// Alias adds aliases created with each index.
+func (t *indexTemplate) Alias(aliases ...*alias) *indexTemplate {
+	t.aliases = append(t.aliases, aliases...)
+	return t
+}
+
+// DataStream makes matching names create data streams. hidden hides them.
+func (t *indexTemplate) DataStream(hidden bool) *indexTemplate {
+	t.dataStream = &hidden
+	return t
+}
+
+// Version sets the user version of the template.
+func (t *indexTemplate) Version(version int64) *indexTemplate {
+	t.version = &version
+	return t
+}
+
+// Meta sets custom metadata of the template.
+func (t *indexTemplate) Meta(key string, value interface{}) *indexTemplate {
+	if t.meta == nil {
+		t.meta = make(map[string]interface{})
+	}
+	t.meta[key] = value
+	return t
+}
+
+// Build returns the body of the PUT _index_template request.
+func (t *indexTemplate) Build() (interface{}, error) {
+	if t.name == "" || len(t.indexPatterns) == 0 {
+		return nil, fmt.Errorf("index template: name and index_patterns must be set")
+	}
+	if t.dataStream != nil && len(t.aliases) > 0 {
+		return nil, fmt.Errorf("index template %s: data streams do not support aliases", t.name)
+	}
+	source := map[string]interface{}{
+		"index_patterns": t.indexPatterns,
+	}
+	if t.priority != nil {
+		source["priority"] = *t.priority
+	}
+	if len(t.composedOf) > 0 {
+		source["composed_of"] = t.composedOf
+	}
+	if t.dataStream != nil {
+		dataStream := make(map[string]interface{})
+		if *t.dataStream {
+			dataStream["hidden"] = true
+		}
+		source["data_stream"] = dataStream
+	}
+	if err := t.build(source); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// Do sends the PUT _index_template request.
+func (t *indexTemplate) Do(ctx context.Context, tr Transport) error {
+	src, err := t.Build()
+	if err != nil {
+		return err
+	}
+	_, err = perform(ctx, tr, "PUT", "_index_template/"+url.PathEscape(t.name), src)
+	return err
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/indices-component-template.html
+type componentTemplate struct {
+	templateBody
+	name string
+}
+
+// NewComponentTemplate creates and initializes a new componentTemplate.
+func NewComponentTemplate(name string) *componentTemplate {
+	return &componentTemplate{name: name}
+}
+
+// Settings sets the index settings of the component.
+func (t *componentTemplate) Settings(settings *indexSettings) *componentTemplate {
+	t.settings = settings
+	return t
+}
+
+// Mappings sets the mapping of the component.
+func (t *componentTemplate) Mappings(mappings *mapping) *componentTemplate {
+	t.mappings = mappings
+	return t
+}
+
+// Alias adds aliases of the component.
+func (t *componentTemplate) Alias(aliases ...*alias) *componentTemplate {
+	t.aliases = append(t.aliases, aliases...)
+	return t
+}
+
+// Version sets the user version of the component.
+func (t *componentTemplate) Version(version int64) *componentTemplate {
+	t.version = &version
+	return t
+}
+
+// Meta sets custom metadata of the component.
+func (t *componentTemplate) Meta(key string, value interface{}) *componentTemplate {
+	if t.meta == nil {
+		t.meta = make(map[string]interface{})
+	}
+	t.meta[key] = value
+	return t
+}
+
+// Build returns the body of the PUT _component_template request.
+func (t *componentTemplate) Build() (interface{}, error) {
+	if t.name == "" {
+		return nil, fmt.Errorf("component template: name must be set")
+	}
+	source := make(map[string]interface{})
+	if err := t.build(source); err != nil {
+		return nil, err
+	}
+	if _, ok := source["template"]; !ok {
+		return nil, fmt.Errorf("component template %s: settings, mappings or aliases must be set", t.name)
+	}
+	return source, nil
+}
+
+// Do sends the PUT _component_template request.
+func (t *componentTemplate) Do(ctx context.Context, tr Transport) error {
+	src, err := t.Build()
+	if err != nil {
+		return err
+	}
+	_, err = perform(ctx, tr, "PUT", "_component_template/"+url.PathEscape(t.name), src)
+	return err
+}
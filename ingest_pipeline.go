@@ -0,0 +1,433 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/pipeline.html
+type ingestPipeline struct {
+	id          string
+	description string
+	processors  []*ingestProcessor
+	onFailure   []*ingestProcessor
+	version     *int
+}
+
+// NewIngestPipeline creates and initializes a new ingestPipeline.
+func NewIngestPipeline(id string, processors ...*ingestProcessor) *ingestPipeline {
+	return &ingestPipeline{
+		id:         id,
+		processors: append(make([]*ingestProcessor, 0), processors...),
+		onFailure:  make([]*ingestProcessor, 0),
+	}
+}
+
+// Description sets the description of the pipeline.
+func (p *ingestPipeline) Description(description string) *ingestPipeline {
+	p.description = description
+	return p
+}
+
+// Processor adds processors, run in order.
+func (p *ingestPipeline) Processor(processors ...*ingestProcessor) *ingestPipeline {
+	p.processors = append(p.processors, processors...)
+	return p
+}
+
+// OnFailure adds processors run when a processor fails without its own
+// failure handling.
+func (p *ingestPipeline) OnFailure(processors ...*ingestProcessor) *ingestPipeline {
+	p.onFailure = append(p.onFailure, processors...)
+	return p
+}
+
+// Version sets the user version of the pipeline.
+func (p *ingestPipeline) Version(version int) *ingestPipeline {
+	p.version = &version
+	return p
+}
+
+// Build returns the body of the PUT _ingest/pipeline request.
+func (p *ingestPipeline) Build() (interface{}, error) {
+	if p.id == "" {
+		return nil, fmt.Errorf("ingest pipeline: id must be set")
+	}
+	if len(p.processors) == 0 {
+		return nil, fmt.Errorf("ingest pipeline %s: at least one processor must be added", p.id)
+	}
+	source := make(map[string]interface{})
+	if p.description != "" {
+		source["description"] = p.description
+	}
+	processors, err := buildIngestProcessors(p.processors)
+	if err != nil {
+		return nil, fmt.Errorf("ingest pipeline %s: %v", p.id, err)
+	}
+	source["processors"] = processors
+	if len(p.onFailure) > 0 {
+		onFailure, err := buildIngestProcessors(p.onFailure)
+		if err != nil {
+			return nil, fmt.Errorf("ingest pipeline %s: on_failure: %v", p.id, err)
+		}
+		source["on_failure"] = onFailure
+	}
+	if p.version != nil {
+		source["version"] = *p.version
+	}
+	return source, nil
+}
+
+// Do sends the PUT _ingest/pipeline request.
+func (p *ingestPipeline) Do(ctx context.Context, t Transport) error {
+	src, err := p.Build()
+	if err != nil {
+		return err
+	}
+	_, err = perform(ctx, t, "PUT", "_ingest/pipeline/"+url.PathEscape(p.id), src)
+	return err
+}
+
+func buildIngestProcessors(processors []*ingestProcessor) ([]interface{}, error) {
+	sources := make([]interface{}, 0, len(processors))
+	for _, processor := range processors {
+		src, err := processor.Build()
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// ingestProcessorParams lists the parameters each processor type accepts
+// besides if, on_failure, ignore_failure and tag. The first ones listed
+// in ingestProcessorRequired must be set.
+var ingestProcessorParams = map[string][]string{
+	"set":       {"field", "value", "copy_from", "override", "ignore_empty_value", "media_type"},
+	"rename":    {"field", "target_field", "ignore_missing"},
+	"remove":    {"field", "ignore_missing"},
+	"convert":   {"field", "type", "target_field", "ignore_missing"},
+	"date":      {"field", "formats", "target_field", "timezone", "locale", "output_format"},
+	"grok":      {"field", "patterns", "pattern_definitions", "trace_match", "ignore_missing"},
+	"dissect":   {"field", "pattern", "append_separator", "ignore_missing"},
+	"split":     {"field", "separator", "target_field", "ignore_missing", "preserve_trailing"},
+	"lowercase": {"field", "target_field", "ignore_missing"},
+	"script":    {},
+	"foreach":   {"field", "ignore_missing"},
+	"pipeline":  {"name"},
+}
+
+var ingestProcessorRequired = map[string][]string{
+	"set":       {"field"},
+	"rename":    {"field", "target_field"},
+	"remove":    {"field"},
+	"convert":   {"field", "type"},
+	"date":      {"field", "formats"},
+	"grok":      {"field", "patterns"},
+	"dissect":   {"field", "pattern"},
+	"split":     {"field", "separator"},
+	"lowercase": {"field"},
+	"foreach":   {"field"},
+	"pipeline":  {"name"},
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/ingest-processors.html
+type ingestProcessor struct {
+	processorType string
+	params        map[string]interface{}
+	condition     string
+	onFailure     []*ingestProcessor
+	ignoreFailure *bool
+	tag           string
+	script        *script
+	processor     *ingestProcessor
+}
+
+// NewIngestProcessor creates a processor of any type. Parameters are set
+// with Param.
+func NewIngestProcessor(processorType string) *ingestProcessor {
+	return &ingestProcessor{
+		processorType: processorType,
+		params:        make(map[string]interface{}),
+		onFailure:     make([]*ingestProcessor, 0),
+	}
+}
+
+// NewSetProcessor sets field to value. String values are mustache
+// templates, e.g. "{{service.name}}".
+func NewSetProcessor(field string, value interface{}) *ingestProcessor {
+	return NewIngestProcessor("set").Param("field", field).Param("value", value)
+}
+
+// NewRenameProcessor renames field to targetField.
+func NewRenameProcessor(field, targetField string) *ingestProcessor {
+	return NewIngestProcessor("rename").Param("field", field).Param("target_field", targetField)
+}
+
+// NewRemoveProcessor removes fields.
+func NewRemoveProcessor(fields ...string) *ingestProcessor {
+	p := NewIngestProcessor("remove")
+	if len(fields) == 1 {
+		return p.Param("field", fields[0])
+	}
+	return p.Param("field", fields)
+}
+
+// NewConvertProcessor converts field to integer, long, float, double,
+// string, boolean, ip or auto.
+func NewConvertProcessor(field, convertType string) *ingestProcessor {
+	return NewIngestProcessor("convert").Param("field", field).Param("type", convertType)
+}
+
+// NewDateProcessor parses field with the first matching format, e.g.
+// ISO8601, UNIX, UNIX_MS or a java time pattern, into @timestamp.
+func NewDateProcessor(field string, formats ...string) *ingestProcessor {
+	return NewIngestProcessor("date").Param("field", field).Param("formats", formats)
+}
+
+// NewGrokProcessor extracts fields from field with the first matching
+// grok pattern.
+func NewGrokProcessor(field string, patterns ...string) *ingestProcessor {
+	return NewIngestProcessor("grok").Param("field", field).Param("patterns", patterns)
+}
+
+// NewDissectProcessor extracts fields from field with a dissect pattern,
+// e.g. "%{clientip} [%{@timestamp}] %{status}".
+func NewDissectProcessor(field, pattern string) *ingestProcessor {
+	return NewIngestProcessor("dissect").Param("field", field).Param("pattern", pattern)
+}
+
+// NewSplitProcessor splits field into an array with the separator regex.
+func NewSplitProcessor(field, separator string) *ingestProcessor {
+	return NewIngestProcessor("split").Param("field", field).Param("separator", separator)
+}
+
+// NewLowercaseProcessor lowercases the string or strings of field.
+func NewLowercaseProcessor(field string) *ingestProcessor {
+	return NewIngestProcessor("lowercase").Param("field", field)
+}
+
+// NewScriptProcessor runs a script on the document.
+func NewScriptProcessor(script *script) *ingestProcessor {
+	p := NewIngestProcessor("script")
+	p.script = script
+	return p
+}
+
+// NewForeachProcessor runs processor on each element of the array field,
+// available as _ingest._value.
+func NewForeachProcessor(field string, processor *ingestProcessor) *ingestProcessor {
+	p := NewIngestProcessor("foreach").Param("field", field)
+	p.processor = processor
+	return p
+}
+
+// NewPipelineProcessor runs the pipeline named name.
+func NewPipelineProcessor(name string) *ingestProcessor {
+	return NewIngestProcessor("pipeline").Param("name", name)
+}
+
+// Param sets any parameter of the processor.
+func (p *ingestProcessor) Param(name string, value interface{}) *ingestProcessor {
+	p.params[name] = value
+	return p
+}
+
+// If runs the processor only when the painless condition is true.
+func (p *ingestProcessor) If(condition string) *ingestProcessor {
+	p.condition = condition
+	return p
+}
+
+// OnFailure adds processors run when this processor fails.
+func (p *ingestProcessor) OnFailure(processors ...*ingestProcessor) *ingestProcessor {
+	p.onFailure = append(p.onFailure, processors...)
+	return p
+}
+
+// IgnoreFailure ignores failures of the processor.
+func (p *ingestProcessor) IgnoreFailure(ignoreFailure bool) *ingestProcessor {
+	p.ignoreFailure = &ignoreFailure
+	return p
+}
+
+// Tag sets an identifier of the processor, reported in failures.
+func (p *ingestProcessor) Tag(tag string) *ingestProcessor {
+	p.tag = tag
+	return p
+}
+
+// TargetField writes the result to targetField instead of the source field.
+func (p *ingestProcessor) TargetField(targetField string) *ingestProcessor {
+	return p.Param("target_field", targetField)
+}
+
+// IgnoreMissing skips documents without the field instead of failing.
+func (p *ingestProcessor) IgnoreMissing(ignoreMissing bool) *ingestProcessor {
+	return p.Param("ignore_missing", ignoreMissing)
+}
+
+// Override sets whether set replaces an existing non-null value.
+func (p *ingestProcessor) Override(override bool) *ingestProcessor {
+	return p.Param("override", override)
+}
+
+// IgnoreEmptyValue skips set when the value renders to an empty string.
+func (p *ingestProcessor) IgnoreEmptyValue(ignoreEmptyValue bool) *ingestProcessor {
+	return p.Param("ignore_empty_value", ignoreEmptyValue)
+}
+
+// Timezone sets the timezone dates are parsed in.
+func (p *ingestProcessor) Timezone(timezone string) *ingestProcessor {
+	return p.Param("timezone", timezone)
+}
+
+// Locale sets the locale of month and day names.
+func (p *ingestProcessor) Locale(locale string) *ingestProcessor {
+	return p.Param("locale", locale)
+}
+
+// OutputFormat sets the java time pattern of the parsed date.
+func (p *ingestProcessor) OutputFormat(outputFormat string) *ingestProcessor {
+	return p.Param("output_format", outputFormat)
+}
+
+// PatternDefinition adds a custom grok pattern.
+func (p *ingestProcessor) PatternDefinition(name, pattern string) *ingestProcessor {
+	definitions, _ := p.stringMapParam("pattern_definitions")
+	if definitions == nil {
+		definitions = make(map[string]string)
+	}
+	definitions[name] = pattern
+	p.params["pattern_definitions"] = definitions
+	return p
+}
+
+// TraceMatch records the index of the matching grok pattern in
+// _ingest._grok_match_index.
+func (p *ingestProcessor) TraceMatch(traceMatch bool) *ingestProcessor {
+	return p.Param("trace_match", traceMatch)
+}
+
+// AppendSeparator sets the separator of dissect keys appended with "+".
+func (p *ingestProcessor) AppendSeparator(separator string) *ingestProcessor {
+	return p.Param("append_separator", separator)
+}
+
+// PreserveTrailing keeps trailing empty fields of split.
+func (p *ingestProcessor) PreserveTrailing(preserveTrailing bool) *ingestProcessor {
+	return p.Param("preserve_trailing", preserveTrailing)
+}
+
+// Build returns the map for the processor.
+func (p *ingestProcessor) Build() (interface{}, error) {
+	if p.processorType == "" {
+		return nil, fmt.Errorf("ingest processor: type must be set")
+	}
+	if allowed, ok := ingestProcessorParams[p.processorType]; ok {
+		names := make([]string, 0, len(p.params))
+		for name := range p.params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !containsString(allowed, name) {
+				return nil, fmt.Errorf("%s processor does not support parameter %s", p.processorType, name)
+			}
+		}
+		for _, name := range ingestProcessorRequired[p.processorType] {
+			if _, ok := p.params[name]; !ok {
+				return nil, fmt.Errorf("%s processor requires %s", p.processorType, name)
+			}
+		}
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	params := make(map[string]interface{}, len(p.params)+4)
+	for name, value := range p.params {
+		params[name] = value
+	}
+	if p.script != nil {
+		src, err := p.script.Build()
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range src.(map[string]interface{}) {
+			params[name] = value
+		}
+	}
+	if p.processor != nil {
+		src, err := p.processor.Build()
+		if err != nil {
+			return nil, fmt.Errorf("foreach: %v", err)
+		}
+		params["processor"] = src
+	}
+	if p.condition != "" {
+		params["if"] = p.condition
+	}
+	if len(p.onFailure) > 0 {
+		onFailure, err := buildIngestProcessors(p.onFailure)
+		if err != nil {
+			return nil, err
+		}
+		params["on_failure"] = onFailure
+	}
+	if p.ignoreFailure != nil {
+		params["ignore_failure"] = *p.ignoreFailure
+	}
+	if p.tag != "" {
+		params["tag"] = p.tag
+	}
+	return map[string]interface{}{
+		p.processorType: params,
+	}, nil
+}
+
+func (p *ingestProcessor) validate() error {
+	switch p.processorType {
+	case "set":
+		_, hasValue := p.params["value"]
+		_, hasCopyFrom := p.params["copy_from"]
+		if hasValue == hasCopyFrom {
+			return fmt.Errorf("set processor requires exactly one of value and copy_from")
+		}
+	case "convert":
+		switch p.params["type"] {
+		case "integer", "long", "float", "double", "string", "boolean", "ip", "auto":
+		default:
+			return fmt.Errorf("convert processor: invalid type %v", p.params["type"])
+		}
+	case "date", "grok":
+		name := "formats"
+		if p.processorType == "grok" {
+			name = "patterns"
+		}
+		values, ok := p.stringsParam(name)
+		if !ok {
+			return fmt.Errorf("%s processor: %s must be a list of strings", p.processorType, name)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("%s processor requires at least one of %s", p.processorType, name)
+		}
+		if _, ok := p.stringMapParam("pattern_definitions"); !ok {
+			return fmt.Errorf("%s processor: pattern_definitions must be a map of strings", p.processorType)
+		}
+	case "script":
+		if p.script == nil {
+			return fmt.Errorf("script processor: script must be set")
+		}
+	case "foreach":
+		if p.processor == nil {
+			return fmt.Errorf("foreach processor: processor must be set")
+		}
+	}
+	return nil
+}
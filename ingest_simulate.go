@@ -0,0 +1,858 @@
+package esbuilder
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ingestSimulator runs ingest pipelines locally so they can be unit
+// tested without a cluster. Painless is not available: scripts and if
+// conditions run the Go functions registered for their source or id.
+type ingestSimulator struct {
+	pipelines  map[string]*ingestPipeline
+	scripts    map[string]func(ctx, params map[string]interface{}) error
+	conditions map[string]func(ctx map[string]interface{}) bool
+	now        func() time.Time
+}
+
+// NewIngestSimulator creates a simulator knowing pipelines, which may be
+// run directly or through pipeline processors.
+func NewIngestSimulator(pipelines ...*ingestPipeline) *ingestSimulator {
+	s := &ingestSimulator{
+		pipelines:  make(map[string]*ingestPipeline),
+		scripts:    make(map[string]func(ctx, params map[string]interface{}) error),
+		conditions: make(map[string]func(ctx map[string]interface{}) bool),
+		now:        time.Now,
+	}
+	return s.Pipeline(pipelines...)
+}
+
+// Pipeline registers pipelines.
+func (s *ingestSimulator) Pipeline(pipelines ...*ingestPipeline) *ingestSimulator {
+	for _, p := range pipelines {
+		s.pipelines[p.id] = p
+	}
+	return s
+}
+
+// Script registers the Go implementation of the script with the given
+// source or stored script id.
+func (s *ingestSimulator) Script(source string, fn func(ctx, params map[string]interface{}) error) *ingestSimulator {
+	s.scripts[source] = fn
+	return s
+}
+
+// Condition registers the Go implementation of an if condition.
+func (s *ingestSimulator) Condition(condition string, fn func(ctx map[string]interface{}) bool) *ingestSimulator {
+	s.conditions[condition] = fn
+	return s
+}
+
+// Now sets the clock used for _ingest.timestamp.
+func (s *ingestSimulator) Now(now func() time.Time) *ingestSimulator {
+	s.now = now
+	return s
+}
+
+// ingestSimulateResult is the outcome of a document. Doc is nil when Err
+// is set.
+type ingestSimulateResult struct {
+	Doc map[string]interface{}
+	Err error
+}
+
+// Run runs the pipeline id on copies of docs. The error is only set when
+// a pipeline is unknown or invalid.
+func (s *ingestSimulator) Run(id string, docs ...map[string]interface{}) ([]*ingestSimulateResult, error) {
+	for _, p := range s.pipelines {
+		if _, err := p.Build(); err != nil {
+			return nil, err
+		}
+	}
+	p, ok := s.pipelines[id]
+	if !ok {
+		return nil, fmt.Errorf("ingest simulator: pipeline with id [%s] does not exist", id)
+	}
+	results := make([]*ingestSimulateResult, 0, len(docs))
+	for _, doc := range docs {
+		d := &ingestDoc{
+			source: copyIngestValue(doc).(map[string]interface{}),
+			ingest: map[string]interface{}{
+				"timestamp": s.now().UTC().Format(time.RFC3339Nano),
+			},
+		}
+		if err := s.runPipeline(d, p, nil); err != nil {
+			results = append(results, &ingestSimulateResult{Err: err})
+			continue
+		}
+		results = append(results, &ingestSimulateResult{Doc: d.source})
+	}
+	return results, nil
+}
+
+func (s *ingestSimulator) runPipeline(d *ingestDoc, p *ingestPipeline, stack []string) error {
+	if containsString(stack, p.id) {
+		return fmt.Errorf("cycle detected for pipeline: %s", p.id)
+	}
+	stack = append(stack, p.id)
+	for _, processor := range p.processors {
+		err := s.runProcessor(d, processor, stack)
+		if err == nil {
+			continue
+		}
+		if len(p.onFailure) == 0 {
+			return err
+		}
+		return s.runOnFailure(d, p.onFailure, processor, err, stack)
+	}
+	return nil
+}
+
+func (s *ingestSimulator) runOnFailure(d *ingestDoc, handlers []*ingestProcessor, failed *ingestProcessor, cause error, stack []string) error {
+	d.ingest["on_failure_message"] = cause.Error()
+	d.ingest["on_failure_processor_type"] = failed.processorType
+	d.ingest["on_failure_processor_tag"] = failed.tag
+	for _, handler := range handlers {
+		if err := s.runProcessor(d, handler, stack); err != nil {
+			return err
+		}
+	}
+	delete(d.ingest, "on_failure_message")
+	delete(d.ingest, "on_failure_processor_type")
+	delete(d.ingest, "on_failure_processor_tag")
+	return nil
+}
+
+func (s *ingestSimulator) runProcessor(d *ingestDoc, p *ingestProcessor, stack []string) error {
+	if p.condition != "" {
+		fn, ok := s.conditions[p.condition]
+		if !ok {
+			return fmt.Errorf("ingest simulator: no Go implementation registered for condition %q", p.condition)
+		}
+		if !fn(d.source) {
+			return nil
+		}
+	}
+	err := s.execute(d, p, stack)
+	if err == nil {
+		return nil
+	}
+	if p.ignoreFailure != nil && *p.ignoreFailure {
+		return nil
+	}
+	if len(p.onFailure) > 0 {
+		return s.runOnFailure(d, p.onFailure, p, err, stack)
+	}
+	return fmt.Errorf("%s processor%s: %v", p.processorType, tagSuffix(p.tag), err)
+}
+
+func tagSuffix(tag string) string {
+	if tag == "" {
+		return ""
+	}
+	return " [" + tag + "]"
+}
+
+func (s *ingestSimulator) execute(d *ingestDoc, p *ingestProcessor, stack []string) error {
+	field := p.stringParam("field")
+	ignoreMissing := p.boolParam("ignore_missing", false)
+	switch p.processorType {
+	case "set":
+		return s.executeSet(d, p)
+	case "remove":
+		fields, ok := p.stringsParam("field")
+		if !ok || fields == nil {
+			fields = []string{field}
+		}
+		for _, f := range fields {
+			if !d.remove(f) && !ignoreMissing {
+				return fmt.Errorf("field [%s] not present as part of path [%s]", f, f)
+			}
+		}
+		return nil
+	case "rename":
+		value, ok := d.get(field)
+		if !ok {
+			if ignoreMissing {
+				return nil
+			}
+			return fmt.Errorf("field [%s] doesn't exist", field)
+		}
+		target := p.stringParam("target_field")
+		if _, exists := d.get(target); exists {
+			return fmt.Errorf("field [%s] already exists", target)
+		}
+		d.remove(field)
+		return d.set(target, value)
+	case "script":
+		key := p.script.source
+		if key == "" {
+			key = p.script.id
+		}
+		fn, ok := s.scripts[key]
+		if !ok {
+			return fmt.Errorf("ingest simulator: no Go implementation registered for script %q", key)
+		}
+		params := p.script.params
+		if params == nil {
+			params = make(map[string]interface{})
+		}
+		return fn(d.source, params)
+	case "pipeline":
+		name, err := d.render(p.stringParam("name"))
+		if err != nil {
+			return err
+		}
+		pipeline, ok := s.pipelines[name]
+		if !ok {
+			return fmt.Errorf("pipeline processor refers to unknown pipeline [%s]", name)
+		}
+		return s.runPipeline(d, pipeline, stack)
+	case "foreach":
+		return s.executeForeach(d, p, stack)
+	}
+
+	value, ok := d.get(field)
+	if !ok || value == nil {
+		if ignoreMissing {
+			return nil
+		}
+		if !ok {
+			return fmt.Errorf("field [%s] not present as part of path [%s]", field, field)
+		}
+		return fmt.Errorf("field [%s] is null, cannot process it", field)
+	}
+	target := p.stringParam("target_field")
+	if target == "" {
+		target = field
+	}
+	switch p.processorType {
+	case "convert":
+		converted, err := convertIngestValue(value, p.stringParam("type"))
+		if err != nil {
+			return err
+		}
+		return d.set(target, converted)
+	case "lowercase":
+		lowered, err := mapIngestStrings(value, field, strings.ToLower)
+		if err != nil {
+			return err
+		}
+		return d.set(target, lowered)
+	case "split":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("field [%s] of type [%T] cannot be cast to string", field, value)
+		}
+		re, err := regexp.Compile(p.stringParam("separator"))
+		if err != nil {
+			return err
+		}
+		parts := re.Split(text, -1)
+		if !p.boolParam("preserve_trailing", false) {
+			for len(parts) > 0 && parts[len(parts)-1] == "" {
+				parts = parts[:len(parts)-1]
+			}
+		}
+		values := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			values = append(values, part)
+		}
+		return d.set(target, values)
+	case "date":
+		if p.params["target_field"] == nil {
+			target = "@timestamp"
+		}
+		return executeDate(d, p, field, target, value)
+	case "grok":
+		return executeGrok(d, p, field, value)
+	case "dissect":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("field [%s] of type [%T] cannot be cast to string", field, value)
+		}
+		pattern, err := parseDissect(p.stringParam("pattern"))
+		if err != nil {
+			return err
+		}
+		fields, err := pattern.match(text, p.stringParam("append_separator"))
+		if err != nil {
+			return err
+		}
+		for _, name := range sortedStringMapKeys(fields) {
+			if err := d.set(name, fields[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("ingest simulator: %s processor is not supported", p.processorType)
+}
+
+func (s *ingestSimulator) executeSet(d *ingestDoc, p *ingestProcessor) error {
+	field, err := d.render(p.stringParam("field"))
+	if err != nil {
+		return err
+	}
+	if !p.boolParam("override", true) {
+		if current, ok := d.get(field); ok && current != nil {
+			return nil
+		}
+	}
+	var value interface{}
+	if from := p.stringParam("copy_from"); from != "" {
+		v, ok := d.get(from)
+		if !ok {
+			return fmt.Errorf("field [%s] not present as part of path [%s]", from, from)
+		}
+		value = copyIngestValue(v)
+	} else {
+		value, err = d.renderValue(p.params["value"])
+		if err != nil {
+			return err
+		}
+	}
+	if p.boolParam("ignore_empty_value", false) && (value == nil || value == "") {
+		return nil
+	}
+	return d.set(field, value)
+}
+
+func (s *ingestSimulator) executeForeach(d *ingestDoc, p *ingestProcessor, stack []string) error {
+	field := p.stringParam("field")
+	value, ok := d.get(field)
+	if !ok || value == nil {
+		if p.boolParam("ignore_missing", false) {
+			return nil
+		}
+		return fmt.Errorf("field [%s] not present as part of path [%s]", field, field)
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("field [%s] of type [%T] cannot be cast to a list", field, value)
+	}
+	previous, hadPrevious := d.ingest["_value"]
+	result := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		d.ingest["_value"] = v
+		if err := s.runProcessor(d, p.processor, stack); err != nil {
+			return err
+		}
+		result = append(result, d.ingest["_value"])
+	}
+	delete(d.ingest, "_value")
+	if hadPrevious {
+		d.ingest["_value"] = previous
+	}
+	return d.set(field, result)
+}
+
+func executeGrok(d *ingestDoc, p *ingestProcessor, field string, value interface{}) error {
+	text, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("field [%s] of type [%T] cannot be cast to string", field, value)
+	}
+	definitions, _ := p.stringMapParam("pattern_definitions")
+	patterns, _ := p.stringsParam("patterns")
+	for i, pattern := range patterns {
+		g, err := compileGrok(pattern, definitions)
+		if err != nil {
+			return err
+		}
+		fields, ok, err := g.match(text)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		for _, name := range sortedMapKeys(fields) {
+			if err := d.set(name, fields[name]); err != nil {
+				return err
+			}
+		}
+		if p.boolParam("trace_match", false) {
+			d.ingest["_grok_match_index"] = strconv.Itoa(i)
+		}
+		return nil
+	}
+	return fmt.Errorf("provided Grok expressions do not match field value: [%s]", text)
+}
+
+func executeDate(d *ingestDoc, p *ingestProcessor, field, target string, value interface{}) error {
+	loc := time.UTC
+	if timezone := p.stringParam("timezone"); timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("invalid timezone %s: %v", timezone, err)
+		}
+	}
+	if locale := p.stringParam("locale"); locale != "" && locale != "ENGLISH" && locale != "ROOT" && !strings.HasPrefix(locale, "en") {
+		return fmt.Errorf("ingest simulator: only english locales are supported, got %s", locale)
+	}
+	text := fmt.Sprint(value)
+	if f, ok := value.(float64); ok {
+		text = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	outputFormat := p.stringParam("output_format")
+	if outputFormat == "" {
+		outputFormat = "yyyy-MM-dd'T'HH:mm:ss.SSSXXX"
+	}
+	outputLayout, err := javaTimeLayout(outputFormat)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	formats, _ := p.stringsParam("formats")
+	for _, format := range formats {
+		t, err := parseIngestDate(text, format, loc)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return d.set(target, t.In(loc).Format(outputLayout))
+	}
+	return fmt.Errorf("unable to parse date [%s]: %v", text, lastErr)
+}
+
+func parseIngestDate(text, format string, loc *time.Location) (time.Time, error) {
+	switch format {
+	case "ISO8601":
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700", "2006-01-02T15:04:05.999999999", "2006-01-02T15:04", "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("not an ISO8601 date")
+	case "UNIX", "UNIX_MS":
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if format == "UNIX" {
+			f *= 1000
+		}
+		ms := int64(math.Round(f))
+		return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond)), nil
+	case "TAI64N":
+		return time.Time{}, fmt.Errorf("ingest simulator: TAI64N is not supported")
+	}
+	layout, err := javaTimeLayout(format)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(layout, text, loc)
+}
+
+// javaTimeLayouts maps java time pattern letters, repeated, to Go layouts.
+var javaTimeLayouts = map[string]string{
+	"yyyy": "2006", "yy": "06", "y": "2006", "uuuu": "2006",
+	"MMMM": "January", "MMM": "Jan", "MM": "01", "M": "1",
+	"dd": "02", "d": "2",
+	"EEEE": "Monday", "EEE": "Mon",
+	"HH": "15", "H": "15",
+	"hh": "03", "h": "3",
+	"mm": "04", "m": "4",
+	"ss": "05", "s": "5",
+	"a":   "PM",
+	"XXX": "Z07:00", "XX": "Z0700", "X": "Z07",
+	"xxx": "-07:00", "xx": "-0700",
+	"ZZZZZ": "-07:00", "ZZ": "-0700", "Z": "-0700",
+	"z": "MST",
+}
+
+// javaTimeLayout converts a java time pattern, such as
+// "dd/MMM/yyyy:HH:mm:ss Z", to a Go time layout.
+func javaTimeLayout(pattern string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		switch {
+		case c == '\'':
+			end := strings.IndexByte(pattern[i+1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("unclosed quote in date format %q", pattern)
+			}
+			if end == 0 {
+				sb.WriteByte('\'')
+			} else {
+				sb.WriteString(pattern[i+1 : i+1+end])
+			}
+			i += end + 2
+		case c == 'S':
+			j := i
+			for j < len(pattern) && pattern[j] == 'S' {
+				j++
+			}
+			sb.WriteString(strings.Repeat("0", j-i))
+			i = j
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			j := i
+			for j < len(pattern) && pattern[j] == c {
+				j++
+			}
+			layout, ok := javaTimeLayouts[pattern[i:j]]
+			if !ok {
+				return "", fmt.Errorf("unsupported date format %q in %q", pattern[i:j], pattern)
+			}
+			sb.WriteString(layout)
+			i = j
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String(), nil
+}
+
+func convertIngestValue(value interface{}, convertType string) (interface{}, error) {
+	if values, ok := value.([]interface{}); ok {
+		converted := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			c, err := convertIngestValue(v, convertType)
+			if err != nil {
+				return nil, err
+			}
+			converted = append(converted, c)
+		}
+		return converted, nil
+	}
+	text := fmt.Sprint(value)
+	if f, ok := value.(float64); ok {
+		text = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	switch convertType {
+	case "integer", "long":
+		n, err := parseIngestInt(text)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert [%s] to %s", text, convertType)
+		}
+		if convertType == "integer" {
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				return nil, fmt.Errorf("unable to convert [%s] to integer", text)
+			}
+			return int(n), nil
+		}
+		return n, nil
+	case "float", "double":
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert [%s] to %s", text, convertType)
+		}
+		if convertType == "float" {
+			return float32(f), nil
+		}
+		return f, nil
+	case "boolean":
+		switch strings.ToLower(text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("[%s] is not a boolean value, cannot convert to boolean", text)
+	case "string":
+		return text, nil
+	case "ip":
+		if net.ParseIP(text) == nil {
+			return nil, fmt.Errorf("'%s' is not an IP string literal", text)
+		}
+		return text, nil
+	case "auto":
+		if _, ok := value.(string); !ok {
+			return value, nil
+		}
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+			return n, nil
+		}
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f, nil
+		}
+		if text == "true" || text == "false" {
+			return text == "true", nil
+		}
+		return text, nil
+	}
+	return nil, fmt.Errorf("invalid convert type %s", convertType)
+}
+
+// parseIngestInt parses a base 10 integer, or a hexadecimal one with a 0x
+// prefix. Unlike strconv.ParseInt with base 0, a leading zero does not
+// switch to octal.
+func parseIngestInt(text string) (int64, error) {
+	digits, negative := text, false
+	if strings.HasPrefix(digits, "-") {
+		digits, negative = digits[1:], true
+	}
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		n, err := strconv.ParseInt(digits[2:], 16, 64)
+		if negative {
+			n = -n
+		}
+		return n, err
+	}
+	return strconv.ParseInt(text, 10, 64)
+}
+
+func mapIngestStrings(value interface{}, field string, fn func(string) string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return fn(v), nil
+	case []interface{}:
+		mapped := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("value [%v] of field [%s] cannot be cast to string", item, field)
+			}
+			mapped = append(mapped, fn(text))
+		}
+		return mapped, nil
+	}
+	return nil, fmt.Errorf("field [%s] of type [%T] cannot be cast to string", field, value)
+}
+
+// ingestDoc is a document going through a pipeline. Paths starting with
+// "_ingest." address the ingest metadata.
+type ingestDoc struct {
+	source map[string]interface{}
+	ingest map[string]interface{}
+}
+
+func (d *ingestDoc) root(path string) (map[string]interface{}, string) {
+	if strings.HasPrefix(path, "_ingest.") {
+		return d.ingest, strings.TrimPrefix(path, "_ingest.")
+	}
+	return d.source, strings.TrimPrefix(path, "_source.")
+}
+
+func (d *ingestDoc) get(path string) (interface{}, bool) {
+	var current interface{}
+	current, path = d.root(path)
+	for _, part := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			value, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			current = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (d *ingestDoc) set(path string, value interface{}) error {
+	current, path := d.root(path)
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part]
+		if !ok || next == nil {
+			child := make(map[string]interface{})
+			current[part] = child
+			current = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot set [%s] with parent object of type [%T]", path, next)
+		}
+		current = child
+	}
+	current[parts[len(parts)-1]] = value
+	return nil
+}
+
+func (d *ingestDoc) remove(path string) bool {
+	current, path := d.root(path)
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := current[part].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		current = child
+	}
+	last := parts[len(parts)-1]
+	if _, ok := current[last]; !ok {
+		return false
+	}
+	delete(current, last)
+	return true
+}
+
+// render renders a mustache template against the document, without the
+// JSON escaping applied to search templates.
+func (d *ingestDoc) render(template string) (string, error) {
+	if !strings.Contains(template, "{{") {
+		return template, nil
+	}
+	nodes, err := parseMustache(template)
+	if err != nil {
+		return "", err
+	}
+	unescapeMustache(nodes)
+	ctx := make(map[string]interface{}, len(d.source)+1)
+	for key, value := range d.source {
+		ctx[key] = value
+	}
+	ctx["_ingest"] = d.ingest
+	var sb strings.Builder
+	if err := renderMustache(&sb, nodes, []interface{}{ctx}); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func (d *ingestDoc) renderValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return d.render(v)
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			r, err := d.renderValue(item)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = r
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			r, err := d.renderValue(item)
+			if err != nil {
+				return nil, err
+			}
+			rendered = append(rendered, r)
+		}
+		return rendered, nil
+	case []string:
+		rendered := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			r, err := d.render(item)
+			if err != nil {
+				return nil, err
+			}
+			rendered = append(rendered, r)
+		}
+		return rendered, nil
+	}
+	return value, nil
+}
+
+func unescapeMustache(nodes []*mustacheNode) {
+	for _, node := range nodes {
+		if node.kind == mustacheVar {
+			node.kind = mustacheRaw
+		}
+		unescapeMustache(node.children)
+	}
+}
+
+func copyIngestValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyIngestValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			copied = append(copied, copyIngestValue(item))
+		}
+		return copied
+	case []string:
+		copied := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			copied = append(copied, item)
+		}
+		return copied
+	}
+	return value
+}
+
+func sortedStringMapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (p *ingestProcessor) stringParam(name string) string {
+	value, _ := p.params[name].(string)
+	return value
+}
+
+// stringsParam returns a list of strings parameter set either by the
+// builders or decoded from JSON. ok is false if the parameter is set to
+// anything else.
+func (p *ingestProcessor) stringsParam(name string) (values []string, ok bool) {
+	switch v := p.params[name].(type) {
+	case nil:
+		return nil, true
+	case []string:
+		return v, true
+	case []interface{}:
+		values = make([]string, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, text)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// stringMapParam is like stringsParam for a map of strings parameter.
+func (p *ingestProcessor) stringMapParam(name string) (values map[string]string, ok bool) {
+	switch v := p.params[name].(type) {
+	case nil:
+		return nil, true
+	case map[string]string:
+		return v, true
+	case map[string]interface{}:
+		values = make(map[string]string, len(v))
+		for key, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values[key] = text
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func (p *ingestProcessor) boolParam(name string, defaultValue bool) bool {
+	if value, ok := p.params[name].(bool); ok {
+		return value
+	}
+	return defaultValue
+}
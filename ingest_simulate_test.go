@@ -0,0 +1,261 @@
+package esbuilder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIngestSimulator(t *testing.T) {
+	tests := []struct {
+		name       string
+		processors []*ingestProcessor
+		doc        map[string]interface{}
+		want       string
+		err        string
+	}{
+		{
+			name:       "set and rename",
+			processors: []*ingestProcessor{NewSetProcessor("a", "{{b}}-x"), NewRenameProcessor("b", "c")},
+			doc:        map[string]interface{}{"b": "v"},
+			want:       `{"a":"v-x","c":"v"}`,
+		},
+		{
+			name:       "remove missing",
+			processors: []*ingestProcessor{NewRemoveProcessor("a", "b")},
+			doc:        map[string]interface{}{"a": 1},
+			err:        "field [b] not present",
+		},
+		{
+			name:       "convert",
+			processors: []*ingestProcessor{NewConvertProcessor("n", "long"), NewConvertProcessor("b", "boolean")},
+			doc:        map[string]interface{}{"n": "010", "b": "TRUE"},
+			want:       `{"n":10,"b":true}`,
+		},
+		{
+			name:       "grok",
+			processors: []*ingestProcessor{NewGrokProcessor("message", `%{WORD:verb} %{NUMBER:bytes:int}`)},
+			doc:        map[string]interface{}{"message": "GET 512"},
+			want:       `{"message":"GET 512","verb":"GET","bytes":512}`,
+		},
+		{
+			name: "grok pattern definitions decoded from JSON",
+			processors: []*ingestProcessor{NewIngestProcessor("grok").
+				Param("field", "message").
+				Param("patterns", []interface{}{"%{LEVEL:level}"}).
+				Param("pattern_definitions", map[string]interface{}{"LEVEL": "low|high"})},
+			doc:  map[string]interface{}{"message": "high"},
+			want: `{"message":"high","level":"high"}`,
+		},
+		{
+			name:       "grok no match",
+			processors: []*ingestProcessor{NewGrokProcessor("message", `%{INT:n}`)},
+			doc:        map[string]interface{}{"message": "abc"},
+			err:        "do not match field value: [abc]",
+		},
+		{
+			name:       "dissect",
+			processors: []*ingestProcessor{NewDissectProcessor("message", "%{a} - %{b}")},
+			doc:        map[string]interface{}{"message": "x - y"},
+			want:       `{"message":"x - y","a":"x","b":"y"}`,
+		},
+		{
+			name:       "date",
+			processors: []*ingestProcessor{NewDateProcessor("ts", "dd/MM/yyyy").TargetField("day")},
+			doc:        map[string]interface{}{"ts": "03/02/2024"},
+			want:       `{"ts":"03/02/2024","day":"2024-02-03T00:00:00.000Z"}`,
+		},
+		{
+			name: "on failure",
+			processors: []*ingestProcessor{NewConvertProcessor("n", "integer").Tag("n").
+				OnFailure(NewSetProcessor("error", "{{_ingest.on_failure_processor_tag}}"))},
+			doc:  map[string]interface{}{"n": "x"},
+			want: `{"n":"x","error":"n"}`,
+		},
+		{
+			name:       "ignore failure",
+			processors: []*ingestProcessor{NewConvertProcessor("n", "integer").IgnoreFailure(true)},
+			doc:        map[string]interface{}{"n": "x"},
+			want:       `{"n":"x"}`,
+		},
+		{
+			name:       "tagged error",
+			processors: []*ingestProcessor{NewConvertProcessor("n", "integer").Tag("n")},
+			doc:        map[string]interface{}{"n": "x"},
+			err:        "convert processor [n]: unable to convert [x] to integer",
+		},
+	}
+	for _, tt := range tests {
+		s := NewIngestSimulator(NewIngestPipeline("p", tt.processors...))
+		results, err := s.Run("p", tt.doc)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		result := results[0]
+		if tt.err != "" {
+			if result.Err == nil || !strings.Contains(result.Err.Error(), tt.err) {
+				t.Errorf("%s: err = %v, want %q", tt.name, result.Err, tt.err)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("%s: %v", tt.name, result.Err)
+			continue
+		}
+		if !jsonEqual(t, result.Doc, tt.want) {
+			t.Errorf("%s: unexpected document", tt.name)
+		}
+	}
+}
+
+func TestIngestSimulatorInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor *ingestProcessor
+		err       string
+	}{
+		{"grok without patterns", NewGrokProcessor("message"), "requires at least one of patterns"},
+		{"grok patterns of numbers", NewIngestProcessor("grok").Param("field", "m").Param("patterns", []interface{}{1}),
+			"patterns must be a list of strings"},
+		{"grok pattern definitions of numbers", NewGrokProcessor("m", "%{X:x}").Param("pattern_definitions", map[string]interface{}{"X": 1}),
+			"pattern_definitions must be a map of strings"},
+		{"date formats", NewIngestProcessor("date").Param("field", "ts").Param("formats", "ISO8601"),
+			"formats must be a list of strings"},
+		{"convert type", NewConvertProcessor("n", "decimal"), "invalid type decimal"},
+	}
+	for _, tt := range tests {
+		_, err := NewIngestSimulator(NewIngestPipeline("p", tt.processor)).Run("p")
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestIngestSimulatorPipelineCycle(t *testing.T) {
+	s := NewIngestSimulator(
+		NewIngestPipeline("a", NewPipelineProcessor("b")),
+		NewIngestPipeline("b", NewPipelineProcessor("a")),
+	).Now(func() time.Time { return time.Unix(0, 0) })
+	results, err := s.Run("a", map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "cycle detected") {
+		t.Errorf("err = %v", results[0].Err)
+	}
+	if _, err := s.Run("c"); err == nil {
+		t.Error("unknown pipeline ran")
+	}
+}
+
+func TestConvertIngestValue(t *testing.T) {
+	tests := []struct {
+		value       interface{}
+		convertType string
+		want        interface{}
+		err         bool
+	}{
+		{"42", "integer", 42, false},
+		{"010", "integer", 10, false},
+		{"-0x1f", "long", int64(-31), false},
+		{"0X10", "long", int64(16), false},
+		{"0b1", "long", nil, true},
+		{"1_000", "long", nil, true},
+		{"3000000000", "integer", nil, true},
+		{"3000000000", "long", int64(3000000000), false},
+		{float64(7), "integer", 7, false},
+		{"1.5", "float", float32(1.5), false},
+		{"yes", "boolean", nil, true},
+		{"10.0.0.1", "ip", "10.0.0.1", false},
+		{"nope", "ip", nil, true},
+		{"12", "auto", 12, false},
+		{"1.5", "auto", 1.5, false},
+		{"true", "auto", true, false},
+		{float64(3), "auto", float64(3), false},
+		{[]interface{}{"1", "2"}, "integer", []interface{}{1, 2}, false},
+	}
+	for _, tt := range tests {
+		got, err := convertIngestValue(tt.value, tt.convertType)
+		if (err != nil) != tt.err {
+			t.Errorf("convert %v to %s: err = %v", tt.value, tt.convertType, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("convert %v to %s = %#v, want %#v", tt.value, tt.convertType, got, tt.want)
+		}
+	}
+}
+
+func TestGrok(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		definitions map[string]string
+		text        string
+		want        map[string]interface{}
+		err         bool
+	}{
+		{`%{IP:client} %{WORD:method}`, nil, "10.0.0.1 GET",
+			map[string]interface{}{"client": "10.0.0.1", "method": "GET"}, false},
+		{`%{NUMBER:n:float}`, nil, "1.5", map[string]interface{}{"n": float64(1.5)}, false},
+		{`%{INT:a.b}`, nil, "7", map[string]interface{}{"a.b": "7"}, false},
+		{`%{ID:id}`, map[string]string{"ID": `[a-z]+-%{INT}`}, "ab-12", map[string]interface{}{"id": "ab-12"}, false},
+		{`%{INT:n}`, nil, "x", nil, false},
+		{`%{MISSING:x}`, nil, "x", nil, true},
+		{`%{LOOP}`, map[string]string{"LOOP": "%{LOOP}"}, "x", nil, true},
+	}
+	for _, tt := range tests {
+		g, err := compileGrok(tt.pattern, tt.definitions)
+		if err != nil {
+			if !tt.err {
+				t.Errorf("%s: %v", tt.pattern, err)
+			}
+			continue
+		}
+		if tt.err {
+			t.Errorf("%s: compiled", tt.pattern)
+			continue
+		}
+		got, ok, err := g.match(tt.text)
+		if err != nil {
+			t.Errorf("%s: %v", tt.pattern, err)
+			continue
+		}
+		if ok != (tt.want != nil) || ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s on %q = %v %v, want %v", tt.pattern, tt.text, got, ok, tt.want)
+		}
+	}
+}
+
+func TestDissect(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    map[string]string
+		err     bool
+	}{
+		{"%{a} %{b}", "x y", map[string]string{"a": "x", "b": "y"}, false},
+		{"[%{ts}] %{msg}", "[t] hello world", map[string]string{"ts": "t", "msg": "hello world"}, false},
+		{"%{a->} %{b}", "x    y", map[string]string{"a": "x", "b": "y"}, false},
+		{"%{+name} %{+name}", "john smith", map[string]string{"name": "john smith"}, false},
+		{"%{+name/2} %{+name/1}", "smith john", map[string]string{"name": "john smith"}, false},
+		{"%{?skip} %{b}", "x y", map[string]string{"b": "y"}, false},
+		{"%{*key} %{&key}", "color red", map[string]string{"color": "red"}, false},
+		{"%{a} - %{b}", "x y", nil, true},
+		{"no keys", "x", nil, true},
+	}
+	for _, tt := range tests {
+		d, err := parseDissect(tt.pattern)
+		if err == nil {
+			var got map[string]string
+			got, err = d.match(tt.text, " ")
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s on %q = %v, want %v", tt.pattern, tt.text, got, tt.want)
+			}
+		}
+		if (err != nil) != tt.err {
+			t.Errorf("%s on %q: err = %v", tt.pattern, tt.text, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package esbuilder
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/inner-hits.html
+type innerHits struct {
+	name          string
+	size          *int
+	from          *int
+	sortItems     []query
+	source        []string
+	fetchSource   *bool
+	highlightItem query
+}
+
+// NewInnerHits creates and initializes a new innerHits.
+func NewInnerHits() *innerHits {
+	return &innerHits{
+		sortItems: make([]query, 0),
+		source:    make([]string, 0),
+	}
+}
+
+// Name is the name used for the inner hit definition in the response.
+func (h *innerHits) Name(name string) *innerHits {
+	h.name = name
+	return h
+}
+
+// Size is the maximum number of hits to return per inner_hits. Defaults to 3.
+func (h *innerHits) Size(size int) *innerHits {
+	h.size = &size
+	return h
+}
+
+// From is the offset from where the first hit is fetched.
+func (h *innerHits) From(from int) *innerHits {
+	h.from = &from
+	return h
+}
+
+// Sort adds sort items for the inner hits.
+func (h *innerHits) Sort(sorts ...query) *innerHits {
+	h.sortItems = append(h.sortItems, sorts...)
+	return h
+}
+
+// Source adds fields of _source to return for the inner hits.
+func (h *innerHits) Source(fields ...string) *innerHits {
+	h.source = append(h.source, fields...)
+	return h
+}
+
+// FetchSource enables or disables returning _source for the inner hits.
+func (h *innerHits) FetchSource(fetchSource bool) *innerHits {
+	h.fetchSource = &fetchSource
+	return h
+}
+
+// Highlight sets the highlight specification for the inner hits.
+func (h *innerHits) Highlight(highlight query) *innerHits {
+	h.highlightItem = highlight
+	return h
+}
+
+// Build returns the map for the inner_hits specification.
+func (h *innerHits) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	if h.name != "" {
+		source["name"] = h.name
+	}
+	if h.size != nil {
+		source["size"] = *h.size
+	}
+	if h.from != nil {
+		source["from"] = *h.from
+	}
+	if len(h.sortItems) > 0 {
+		var clauses []interface{}
+		for _, sort := range h.sortItems {
+			src, err := sort.Build()
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, src)
+		}
+		source["sort"] = clauses
+	}
+	if len(h.source) > 0 {
+		source["_source"] = h.source
+	} else if h.fetchSource != nil {
+		source["_source"] = *h.fetchSource
+	}
+	if h.highlightItem != nil {
+		src, err := h.highlightItem.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["highlight"] = src
+	}
+	return source, nil
+}
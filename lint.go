@@ -0,0 +1,430 @@
+package esbuilder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// mappingLinter checks built search requests against registered index
+// mappings.
+type mappingLinter struct {
+	fields map[string]*lintField
+}
+
+type lintField struct {
+	fieldType string
+	fielddata bool
+	docValues bool
+	indexed   bool
+}
+
+// lintIssue is a problem found by the linter. Path locates the clause in
+// the request body, e.g. "query.bool.filter[0].term".
+type lintIssue struct {
+	Path    string
+	Field   string
+	Message string
+}
+
+func (i *lintIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Path, i.Field, i.Message)
+}
+
+// NewMappingLinter creates and initializes a new mappingLinter.
+func NewMappingLinter() *mappingLinter {
+	return &mappingLinter{fields: make(map[string]*lintField)}
+}
+
+// RegisterMapping registers the fields of a mapping built with NewMapping.
+func (l *mappingLinter) RegisterMapping(m *mapping) error {
+	src, err := m.Build()
+	if err != nil {
+		return err
+	}
+	l.registerMappings(src.(map[string]interface{}))
+	return nil
+}
+
+// RegisterMappingJSON registers the fields of a mapping given as JSON. It
+// accepts the mappings object itself, a create index body with a
+// "mappings" key, or the response of GET /index/_mapping.
+func (l *mappingLinter) RegisterMappingJSON(data []byte) error {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	src := make(map[string]interface{})
+	if err := json.Unmarshal(data, &src); err != nil {
+		return err
+	}
+	if _, ok := src["properties"]; ok {
+		l.registerMappings(src)
+		return nil
+	}
+	if mappings, ok := src["mappings"].(map[string]interface{}); ok {
+		l.registerMappings(mappings)
+		return nil
+	}
+	found := false
+	for _, index := range src {
+		if m, ok := index.(map[string]interface{}); ok {
+			if mappings, ok := m["mappings"].(map[string]interface{}); ok {
+				l.registerMappings(mappings)
+				found = true
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("lint: no mappings found")
+	}
+	return nil
+}
+
+func (l *mappingLinter) registerMappings(mappings map[string]interface{}) {
+	if props, ok := mappings["properties"].(map[string]interface{}); ok {
+		l.registerProperties(props, "")
+	}
+	if runtime, ok := mappings["runtime"].(map[string]interface{}); ok {
+		l.registerRuntime(runtime)
+	}
+}
+
+func (l *mappingLinter) registerProperties(props map[string]interface{}, prefix string) {
+	for name, value := range props {
+		def, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := prefix + name
+		fieldType, _ := def["type"].(string)
+		if fieldType == "" {
+			fieldType = "object"
+		}
+		field := &lintField{fieldType: fieldType, docValues: true, indexed: true}
+		if v, ok := def["fielddata"].(bool); ok {
+			field.fielddata = v
+		}
+		if v, ok := def["doc_values"].(bool); ok {
+			field.docValues = v
+		}
+		if v, ok := def["index"].(bool); ok {
+			field.indexed = v
+		}
+		if fieldType == "alias" {
+			if target, ok := def["path"].(string); ok {
+				defer func(path, target string) {
+					if f, ok := l.fields[target]; ok {
+						l.fields[path] = f
+					}
+				}(path, target)
+			}
+		}
+		l.fields[path] = field
+		if sub, ok := def["properties"].(map[string]interface{}); ok {
+			l.registerProperties(sub, path+".")
+		}
+		if sub, ok := def["fields"].(map[string]interface{}); ok {
+			l.registerProperties(sub, path+".")
+		}
+	}
+}
+
+func (l *mappingLinter) registerRuntime(runtime map[string]interface{}) {
+	for name, value := range runtime {
+		fieldType := "keyword"
+		if def, ok := value.(map[string]interface{}); ok {
+			if t, ok := def["type"].(string); ok {
+				fieldType = t
+			}
+		}
+		l.fields[name] = &lintField{fieldType: fieldType, docValues: true, indexed: true}
+	}
+}
+
+// Lint builds dsl and reports unknown fields, term level queries on
+// analyzed text fields, range queries on types without a meaningful
+// order, aggregations and sorts on fields without doc values or
+// fielddata, and knn searches on fields that are not dense vectors.
+func (l *mappingLinter) Lint(dsl *dsl) ([]*lintIssue, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	src, err := dsl.Build()
+	if err != nil {
+		return nil, err
+	}
+	// Some builders return structs, decode the JSON to walk plain maps.
+	data, err := json.Marshal(src)
+	if err != nil {
+		return nil, err
+	}
+	body := make(map[string]interface{})
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	run := &lintRun{linter: l, fields: make(map[string]*lintField), issues: make([]*lintIssue, 0)}
+	if runtime, ok := body["runtime_mappings"].(map[string]interface{}); ok {
+		for name, value := range runtime {
+			fieldType := "keyword"
+			if def, ok := value.(map[string]interface{}); ok {
+				if t, ok := def["type"].(string); ok {
+					fieldType = t
+				}
+			}
+			run.fields[name] = &lintField{fieldType: fieldType, docValues: true, indexed: true}
+		}
+	}
+	if q, ok := body["query"]; ok {
+		run.query("query", q)
+	}
+	if q, ok := body["post_filter"]; ok {
+		run.query("post_filter", q)
+	}
+	if aggs, ok := body["aggs"].(map[string]interface{}); ok {
+		run.aggs("aggs", aggs)
+	}
+	if s, ok := body["sort"]; ok {
+		run.sort("sort", s)
+	}
+	return run.issues, nil
+}
+
+type lintRun struct {
+	linter *mappingLinter
+	fields map[string]*lintField
+	issues []*lintIssue
+}
+
+func (r *lintRun) report(path, field, format string, args ...interface{}) {
+	r.issues = append(r.issues, &lintIssue{Path: path, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// field looks up a field, reporting it if it is unknown. Metadata fields
+// and patterns are not checked.
+func (r *lintRun) field(path, name string) *lintField {
+	if name == "" || strings.HasPrefix(name, "_") || strings.ContainsAny(name, "*?") {
+		return nil
+	}
+	if f, ok := r.fields[name]; ok {
+		return f
+	}
+	if f, ok := r.linter.fields[name]; ok {
+		return f
+	}
+	r.report(path, name, "unknown field")
+	return nil
+}
+
+// Parameters of leaf queries that sit next to the field names.
+var lintQueryParams = map[string]bool{
+	"boost": true, "_name": true, "distance": true, "distance_type": true,
+	"validation_method": true, "ignore_unmapped": true, "relation": true,
+}
+
+func isRangeable(fieldType string) bool {
+	switch fieldType {
+	case "long", "integer", "short", "byte", "double", "float", "half_float", "scaled_float",
+		"unsigned_long", "date", "date_nanos", "ip", "integer_range", "float_range",
+		"long_range", "double_range", "date_range", "ip_range", "version":
+		return true
+	}
+	return false
+}
+
+func (r *lintRun) query(path string, src interface{}) {
+	q, ok := src.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, kind := range sortedMapKeys(q) {
+		body := q[kind]
+		p := path + "." + kind
+		params, _ := body.(map[string]interface{})
+		switch kind {
+		case "bool":
+			for _, clause := range []string{"must", "must_not", "filter", "should"} {
+				r.queries(p+"."+clause, params[clause])
+			}
+		case "dis_max":
+			r.queries(p+".queries", params["queries"])
+		case "constant_score":
+			r.query(p+".filter", params["filter"])
+		case "boosting":
+			r.query(p+".positive", params["positive"])
+			r.query(p+".negative", params["negative"])
+		case "function_score":
+			r.query(p+".query", params["query"])
+			if functions, ok := params["functions"].([]interface{}); ok {
+				for i, fn := range functions {
+					if m, ok := fn.(map[string]interface{}); ok {
+						r.query(fmt.Sprintf("%s.functions[%d].filter", p, i), m["filter"])
+					}
+				}
+			}
+		case "nested":
+			if nestedPath, ok := params["path"].(string); ok {
+				if f := r.field(p, nestedPath); f != nil && f.fieldType != "nested" {
+					r.report(p, nestedPath, "nested query on %s field", f.fieldType)
+				}
+			}
+			r.query(p+".query", params["query"])
+		case "has_child", "has_parent":
+			r.query(p+".query", params["query"])
+		case "exists":
+			if name, ok := params["field"].(string); ok {
+				r.field(p, name)
+			}
+		case "multi_match", "query_string", "simple_query_string":
+			if fields, ok := params["fields"].([]interface{}); ok {
+				for _, f := range fields {
+					if name, ok := f.(string); ok {
+						r.field(p, strings.Split(name, "^")[0])
+					}
+				}
+			}
+		case "knn":
+			for name, value := range params {
+				if f := r.field(p, name); f != nil && f.fieldType != "dense_vector" {
+					r.report(p, name, "knn on %s field, expected dense_vector", f.fieldType)
+				}
+				if m, ok := value.(map[string]interface{}); ok {
+					r.query(p+"."+name+".filter", m["filter"])
+				}
+			}
+		case "term", "terms", "prefix", "wildcard", "regexp", "fuzzy", "range",
+			"match", "match_phrase", "match_phrase_prefix", "match_bool_prefix",
+			"geo_distance", "geo_bounding_box", "geo_polygon", "geo_shape":
+			for _, name := range sortedMapKeys(params) {
+				if lintQueryParams[name] {
+					continue
+				}
+				f := r.field(p, name)
+				if f == nil {
+					continue
+				}
+				switch kind {
+				case "term", "terms", "prefix", "wildcard", "regexp", "fuzzy":
+					if f.fieldType == "text" {
+						r.report(p, name, "%s query on analyzed text field, use match or a keyword sub-field", kind)
+					}
+				case "range":
+					if !isRangeable(f.fieldType) {
+						r.report(p, name, "range query on %s field", f.fieldType)
+					}
+				case "geo_distance", "geo_bounding_box", "geo_polygon":
+					if f.fieldType != "geo_point" {
+						r.report(p, name, "%s query on %s field, expected geo_point", kind, f.fieldType)
+					}
+				case "geo_shape":
+					if f.fieldType != "geo_shape" && f.fieldType != "geo_point" {
+						r.report(p, name, "geo_shape query on %s field", f.fieldType)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (r *lintRun) queries(path string, src interface{}) {
+	switch v := src.(type) {
+	case []interface{}:
+		for i, q := range v {
+			r.query(fmt.Sprintf("%s[%d]", path, i), q)
+		}
+	case map[string]interface{}:
+		r.query(path, v)
+	}
+}
+
+func (r *lintRun) aggs(path string, aggs map[string]interface{}) {
+	for _, name := range sortedMapKeys(aggs) {
+		agg, ok := aggs[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		p := path + "." + name
+		for _, kind := range sortedMapKeys(agg) {
+			params, ok := agg[kind].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if kind == "aggs" || kind == "aggregations" {
+				r.aggs(p+"."+kind, params)
+				continue
+			}
+			if kind == "filter" {
+				r.query(p+".filter", params)
+				continue
+			}
+			field, ok := params["field"].(string)
+			if !ok {
+				continue
+			}
+			f := r.field(p+"."+kind, field)
+			if f == nil {
+				continue
+			}
+			if f.fieldType == "text" && !f.fielddata {
+				r.report(p+"."+kind, field, "aggregation on text field without fielddata, use a keyword sub-field")
+			} else if !f.docValues {
+				r.report(p+"."+kind, field, "aggregation on field without doc_values")
+			}
+		}
+	}
+}
+
+func (r *lintRun) sort(path string, src interface{}) {
+	items, ok := src.([]interface{})
+	if !ok {
+		items = []interface{}{src}
+	}
+	for i, item := range items {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		if len(items) == 1 {
+			p = path
+		}
+		var names []string
+		switch v := item.(type) {
+		case string:
+			names = []string{v}
+		case map[string]interface{}:
+			names = sortedMapKeys(v)
+			if geo, ok := v["_geo_distance"].(map[string]interface{}); ok {
+				for _, name := range sortedMapKeys(geo) {
+					if lintGeoSortParams[name] {
+						continue
+					}
+					if f := r.field(p, name); f != nil && f.fieldType != "geo_point" {
+						r.report(p, name, "_geo_distance sort on %s field", f.fieldType)
+					}
+				}
+			}
+		}
+		for _, name := range names {
+			f := r.field(p, name)
+			if f == nil {
+				continue
+			}
+			switch {
+			case f.fieldType == "text" && !f.fielddata:
+				r.report(p, name, "sort on text field without fielddata, use a keyword sub-field")
+			case f.fieldType == "object" || f.fieldType == "nested" || f.fieldType == "dense_vector" ||
+				f.fieldType == "geo_shape" || f.fieldType == "geo_point" || f.fieldType == "flattened":
+				r.report(p, name, "sort on unsortable %s field", f.fieldType)
+			case !f.docValues:
+				r.report(p, name, "sort on field without doc_values")
+			}
+		}
+	}
+}
+
+var lintGeoSortParams = map[string]bool{
+	"order": true, "unit": true, "mode": true, "distance_type": true, "ignore_unmapped": true, "nested": true,
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
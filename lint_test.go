@@ -0,0 +1,129 @@
+package esbuilder
+
+import (
+	"strings"
+	"testing"
+)
+
+const testLintMapping = `{"products":{"mappings":{
+	"properties":{
+		"title":{"type":"text","fields":{"raw":{"type":"keyword"}}},
+		"body":{"type":"text","fielddata":true},
+		"price":{"type":"double"},
+		"sku":{"type":"keyword","doc_values":false},
+		"active":{"type":"boolean"},
+		"location":{"type":"geo_point"},
+		"vector":{"type":"dense_vector","dims":3},
+		"reviews":{"type":"nested","properties":{"stars":{"type":"integer"}}},
+		"brand":{"properties":{"name":{"type":"keyword"}}},
+		"name":{"type":"alias","path":"title.raw"}
+	},
+	"runtime":{"day":{"type":"keyword"}}
+}}}`
+
+func TestMappingLinter(t *testing.T) {
+	linter := NewMappingLinter()
+	if err := linter.RegisterMappingJSON([]byte(testLintMapping)); err != nil {
+		t.Fatal(err)
+	}
+	withQuery := func(q query) *dsl {
+		d := NewDsl()
+		d.SetQuery(q)
+		return d
+	}
+	withAggs := func(field string) *dsl {
+		d := NewDsl()
+		d.SetAggs(NewAggsQuery("a").Terms(NewAggsTerm(field, 10)))
+		return d
+	}
+	withSort := func(field string) *dsl {
+		d := NewDsl()
+		d.SetOrder(NewSortQuery(field, "asc"))
+		return d
+	}
+	tests := []struct {
+		name   string
+		dsl    *dsl
+		issues []string
+	}{
+		{"match on text", withQuery(NewMatchQuery("title", "shoes")), nil},
+		{"term on keyword sub-field", withQuery(NewTermQuery("title.raw", "shoes")), nil},
+		{"term on alias", withQuery(NewTermQuery("name", "shoes")), nil},
+		{"term on object property", withQuery(NewTermQuery("brand.name", "acme")), nil},
+		{"term on runtime field", withQuery(NewTermQuery("day", "mon")), nil},
+		{"metadata field", withQuery(NewTermQuery("_id", "1")), nil},
+		{"unknown field", withQuery(NewTermQuery("color", "red")),
+			[]string{"query.term: color: unknown field"}},
+		{"term on text", withQuery(NewTermQuery("title", "shoes")),
+			[]string{"query.term: title: term query on analyzed text field, use match or a keyword sub-field"}},
+		{"range on boolean", withQuery(NewRangeQuery("active").Gte(1)),
+			[]string{"query.range: active: range query on boolean field"}},
+		{"range on double", withQuery(NewRangeQuery("price").Gte(1)), nil},
+		{"bool clauses", withQuery(NewBoolQuery().Must(NewMatchQuery("title", "a")).Filter(NewTermQuery("title", "b"), NewTermQuery("colour", "c"))),
+			[]string{
+				"query.bool.filter[0].term: title: term query on analyzed text field, use match or a keyword sub-field",
+				"query.bool.filter[1].term: colour: unknown field",
+			}},
+		{"nested on object", withQuery(NewNestedQuery("brand", NewTermQuery("brand.name", "a"))),
+			[]string{"query.nested: brand: nested query on object field"}},
+		{"nested on nested", withQuery(NewNestedQuery("reviews", NewRangeQuery("reviews.stars").Gte(4))), nil},
+		{"terms agg on keyword", withAggs("title.raw"), nil},
+		{"terms agg on text", withAggs("title"),
+			[]string{"aggs.a.terms: title: aggregation on text field without fielddata, use a keyword sub-field"}},
+		{"terms agg with fielddata", withAggs("body"), nil},
+		{"terms agg without doc_values", withAggs("sku"),
+			[]string{"aggs.a.terms: sku: aggregation on field without doc_values"}},
+		{"sort on double", withSort("price"), nil},
+		{"sort on text", withSort("title"),
+			[]string{"sort: title: sort on text field without fielddata, use a keyword sub-field"}},
+		{"sort on geo_point", withSort("location"),
+			[]string{"sort: location: sort on unsortable geo_point field"}},
+		{"sort on score", withSort("_score"), nil},
+	}
+	for _, tt := range tests {
+		issues, err := linter.Lint(tt.dsl)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got := make([]string, 0, len(issues))
+		for _, issue := range issues {
+			got = append(got, issue.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.issues, "\n") {
+			t.Errorf("%s: issues\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.issues, "\n"))
+		}
+	}
+}
+
+func TestMappingLinterErrors(t *testing.T) {
+	linter := NewMappingLinter()
+	if err := linter.RegisterMappingJSON([]byte(`{"products":{}}`)); err == nil {
+		t.Error("mapping without mappings accepted")
+	}
+	if err := linter.RegisterMappingJSON([]byte(`{`)); err == nil {
+		t.Error("invalid JSON accepted")
+	}
+	d := NewDsl()
+	d.SetQuery(NewBoostingQuery())
+	if _, err := linter.Lint(d); err == nil {
+		t.Error("invalid query linted")
+	}
+}
+
+func TestMappingLinterRegisterMapping(t *testing.T) {
+	linter := NewMappingLinter()
+	m := NewMapping().Property("title", NewTextField()).Property("tag", NewKeywordField())
+	if err := linter.RegisterMapping(m); err != nil {
+		t.Fatal(err)
+	}
+	d := NewDsl()
+	d.SetQuery(NewBoolQuery().Filter(NewTermQuery("tag", "a"), NewTermQuery("title", "b")))
+	issues, err := linter.Lint(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Field != "title" {
+		t.Errorf("issues %v", issues)
+	}
+}
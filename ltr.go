@@ -0,0 +1,203 @@
+package esbuilder
+
+import (
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// sltrQuery runs a learning to rank model or feature set of the LTR plugin.
+// For more details, see
+// https://elasticsearch-learning-to-rank.readthedocs.io/en/latest/searching-with-your-model.html
+type sltrQuery struct {
+	queryName      string
+	model          string
+	featureset     string
+	store          string
+	params         map[string]interface{}
+	activeFeatures []string
+}
+
+// NewSltrQuery creates and initializes a new sltrQuery.
+func NewSltrQuery() *sltrQuery {
+	return &sltrQuery{
+		params:         make(map[string]interface{}),
+		activeFeatures: make([]string, 0),
+	}
+}
+
+// QueryName names the query so its features can be logged.
+func (q *sltrQuery) QueryName(queryName string) *sltrQuery {
+	q.queryName = queryName
+	return q
+}
+
+// Model sets the model used to score documents.
+func (q *sltrQuery) Model(model string) *sltrQuery {
+	q.model = model
+	return q
+}
+
+// Featureset sets the feature set executed, typically for feature logging.
+func (q *sltrQuery) Featureset(featureset string) *sltrQuery {
+	q.featureset = featureset
+	return q
+}
+
+// Store sets the feature store. Defaults to the default store.
+func (q *sltrQuery) Store(store string) *sltrQuery {
+	q.store = store
+	return q
+}
+
+// Param sets a parameter passed to the feature templates.
+func (q *sltrQuery) Param(name string, value interface{}) *sltrQuery {
+	q.params[name] = value
+	return q
+}
+
+// ActiveFeatures restricts the executed features.
+func (q *sltrQuery) ActiveFeatures(features ...string) *sltrQuery {
+	q.activeFeatures = append(q.activeFeatures, features...)
+	return q
+}
+
+func (q *sltrQuery) Build() (interface{}, error) {
+	if (q.model == "") == (q.featureset == "") {
+		return nil, fmt.Errorf("sltr: exactly one of model and featureset must be set")
+	}
+	params := map[string]interface{}{
+		"params": q.params,
+	}
+	if q.queryName != "" {
+		params["_name"] = q.queryName
+	}
+	if q.model != "" {
+		params["model"] = q.model
+	}
+	if q.featureset != "" {
+		params["featureset"] = q.featureset
+	}
+	if q.store != "" {
+		params["store"] = q.store
+	}
+	if len(q.activeFeatures) > 0 {
+		params["active_features"] = q.activeFeatures
+	}
+	return map[string]interface{}{
+		"sltr": params,
+	}, nil
+}
+
+// ltrLog logs feature values of sltr queries into the search hits.
+// For more details, see
+// https://elasticsearch-learning-to-rank.readthedocs.io/en/latest/logging-features.html
+type ltrLog struct {
+	specs []*ltrLogSpec
+}
+
+type ltrLogSpec struct {
+	name          string
+	namedQuery    string
+	rescoreIndex  *int
+	missingAsZero bool
+}
+
+// NewLtrLog creates and initializes a new ltrLog ext section.
+func NewLtrLog() *ltrLog {
+	return &ltrLog{specs: make([]*ltrLogSpec, 0)}
+}
+
+// LogNamedQuery logs the features of the sltr query named namedQuery under name.
+func (l *ltrLog) LogNamedQuery(name, namedQuery string, missingAsZero bool) *ltrLog {
+	l.specs = append(l.specs, &ltrLogSpec{name: name, namedQuery: namedQuery, missingAsZero: missingAsZero})
+	return l
+}
+
+// LogRescore logs the features of the sltr query used by the rescorer at
+// rescoreIndex under name.
+func (l *ltrLog) LogRescore(name string, rescoreIndex int, missingAsZero bool) *ltrLog {
+	l.specs = append(l.specs, &ltrLogSpec{name: name, rescoreIndex: &rescoreIndex, missingAsZero: missingAsZero})
+	return l
+}
+
+// Build returns the map for the ext section.
+func (l *ltrLog) Build() (interface{}, error) {
+	if len(l.specs) == 0 {
+		return nil, fmt.Errorf("ltr_log: at least one log spec must be set")
+	}
+	var specs []interface{}
+	for _, spec := range l.specs {
+		src := make(map[string]interface{})
+		if spec.name != "" {
+			src["name"] = spec.name
+		}
+		if spec.rescoreIndex != nil {
+			src["rescore_index"] = *spec.rescoreIndex
+		} else {
+			src["named_query"] = spec.namedQuery
+		}
+		if spec.missingAsZero {
+			src["missing_as_zero"] = true
+		}
+		specs = append(specs, src)
+	}
+	var logSpecs interface{} = specs
+	if len(specs) == 1 {
+		logSpecs = specs[0]
+	}
+	return map[string]interface{}{
+		"ltr_log": map[string]interface{}{
+			"log_specs": logSpecs,
+		},
+	}, nil
+}
+
+type ltrFeature struct {
+	Name  string   `json:"name"`
+	Value *float64 `json:"value,omitempty"`
+}
+
+// LtrFeatures returns the feature values logged under name for the hit.
+// Features without a value are left out.
+func (h *searchHit) LtrFeatures(name string) (map[string]float64, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	features := make(map[string]float64)
+	raw, ok := h.Fields["_ltrlog"]
+	if !ok {
+		return features, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var logs []map[string][]ltrFeature
+	if err := json.Unmarshal(data, &logs); err != nil {
+		return nil, fmt.Errorf("ltr_log: %v", err)
+	}
+	for _, log := range logs {
+		for _, f := range log[name] {
+			if f.Value != nil {
+				features[f.Name] = *f.Value
+			}
+		}
+	}
+	return features, nil
+}
+
+// LtrFeatures returns the feature values logged under name for each hit,
+// aligned with Hits.Hits.
+func (r *searchResponse) LtrFeatures(name string) ([]map[string]float64, error) {
+	if r.Hits == nil {
+		return make([]map[string]float64, 0), nil
+	}
+	logs := make([]map[string]float64, len(r.Hits.Hits))
+	for i, hit := range r.Hits.Hits {
+		features, err := hit.LtrFeatures(name)
+		if err != nil {
+			return nil, err
+		}
+		logs[i] = features
+	}
+	return logs, nil
+}
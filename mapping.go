@@ -0,0 +1,702 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/mapping.html
+type mapping struct {
+	dynamic          interface{}
+	properties       map[string]*fieldMapping
+	runtime          map[string]*runtimeField
+	dynamicTemplates []*dynamicTemplate
+	routingRequired  *bool
+	sourceEnabled    *bool
+	meta             map[string]interface{}
+}
+
+// NewMapping creates and initializes a new mapping.
+func NewMapping() *mapping {
+	return &mapping{
+		properties:       make(map[string]*fieldMapping),
+		runtime:          make(map[string]*runtimeField),
+		dynamicTemplates: make([]*dynamicTemplate, 0),
+	}
+}
+
+// Dynamic sets how unknown fields are handled: true, false, "strict" or "runtime".
+func (m *mapping) Dynamic(dynamic interface{}) *mapping {
+	m.dynamic = dynamic
+	return m
+}
+
+// Property adds a field to the mapping.
+func (m *mapping) Property(name string, field *fieldMapping) *mapping {
+	m.properties[name] = field
+	return m
+}
+
+// Runtime adds a runtime field to the mapping.
+func (m *mapping) Runtime(name string, field *runtimeField) *mapping {
+	m.runtime[name] = field
+	return m
+}
+
+// DynamicTemplate adds dynamic templates, matched in order.
+func (m *mapping) DynamicTemplate(templates ...*dynamicTemplate) *mapping {
+	m.dynamicTemplates = append(m.dynamicTemplates, templates...)
+	return m
+}
+
+// RoutingRequired requires a routing value for every document.
+func (m *mapping) RoutingRequired(required bool) *mapping {
+	m.routingRequired = &required
+	return m
+}
+
+// SourceEnabled disables storing _source when set to false.
+func (m *mapping) SourceEnabled(enabled bool) *mapping {
+	m.sourceEnabled = &enabled
+	return m
+}
+
+// Meta sets custom metadata of the mapping.
+func (m *mapping) Meta(key string, value interface{}) *mapping {
+	if m.meta == nil {
+		m.meta = make(map[string]interface{})
+	}
+	m.meta[key] = value
+	return m
+}
+
+// Build returns the map for the mappings section.
+func (m *mapping) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	if m.dynamic != nil {
+		source["dynamic"] = m.dynamic
+	}
+	if len(m.properties) > 0 {
+		props, err := buildProperties(m.properties)
+		if err != nil {
+			return nil, err
+		}
+		source["properties"] = props
+	}
+	if len(m.runtime) > 0 {
+		runtime := make(map[string]interface{})
+		for name, field := range m.runtime {
+			src, err := field.Build()
+			if err != nil {
+				return nil, fmt.Errorf("runtime field %s: %v", name, err)
+			}
+			runtime[name] = src
+		}
+		source["runtime"] = runtime
+	}
+	if len(m.dynamicTemplates) > 0 {
+		var templates []interface{}
+		for _, t := range m.dynamicTemplates {
+			src, err := t.Build()
+			if err != nil {
+				return nil, err
+			}
+			templates = append(templates, src)
+		}
+		source["dynamic_templates"] = templates
+	}
+	if m.routingRequired != nil {
+		source["_routing"] = map[string]interface{}{"required": *m.routingRequired}
+	}
+	if m.sourceEnabled != nil {
+		source["_source"] = map[string]interface{}{"enabled": *m.sourceEnabled}
+	}
+	if len(m.meta) > 0 {
+		source["_meta"] = m.meta
+	}
+	return source, nil
+}
+
+func buildProperties(properties map[string]*fieldMapping) (map[string]interface{}, error) {
+	props := make(map[string]interface{}, len(properties))
+	for name, field := range properties {
+		if name == "" {
+			return nil, fmt.Errorf("mapping: field name can not be empty")
+		}
+		if field == nil {
+			return nil, fmt.Errorf("mapping: field %s has no mapping", name)
+		}
+		src, err := field.Build()
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", name, err)
+		}
+		props[name] = src
+	}
+	return props, nil
+}
+
+// fieldMapping is the mapping of one field. The constructors select the
+// field type and Build rejects parameters the type does not support.
+type fieldMapping struct {
+	fieldType  string
+	params     map[string]interface{}
+	fields     map[string]*fieldMapping
+	properties map[string]*fieldMapping
+}
+
+// Parameters supported by every field type are not listed.
+var fieldMappingParams = map[string][]string{
+	"keyword":            {"index", "store", "doc_values", "null_value", "copy_to", "ignore_above", "normalizer", "similarity", "eager_global_ordinals", "fields"},
+	"text":               {"index", "store", "copy_to", "analyzer", "search_analyzer", "search_quote_analyzer", "fielddata", "index_options", "norms", "term_vector", "position_increment_gap", "similarity", "fields"},
+	"long":               {"index", "store", "doc_values", "null_value", "copy_to", "coerce", "ignore_malformed", "fields"},
+	"integer":            {"index", "store", "doc_values", "null_value", "copy_to", "coerce", "ignore_malformed", "fields"},
+	"short":              {"index", "store", "doc_values", "null_value", "copy_to", "coerce", "ignore_malformed", "fields"},
+	"byte":               {"index", "store", "doc_values", "null_value", "copy_to", "coerce", "ignore_malformed", "fields"},
+	"double":             {"index", "store", "doc_values", "null_value", "copy_to", "coerce", "ignore_malformed", "fields"},
+	"float":              {"index", "store", "doc_values", "null_value", "copy_to", "coerce", "ignore_malformed", "fields"},
+	"half_float":         {"index", "store", "doc_values", "null_value", "copy_to", "coerce", "ignore_malformed", "fields"},
+	"scaled_float":       {"index", "store", "doc_values", "null_value", "copy_to", "coerce", "ignore_malformed", "scaling_factor", "fields"},
+	"unsigned_long":      {"index", "store", "doc_values", "null_value", "copy_to", "ignore_malformed", "fields"},
+	"date":               {"index", "store", "doc_values", "null_value", "copy_to", "format", "locale", "ignore_malformed", "fields"},
+	"date_nanos":         {"index", "store", "doc_values", "null_value", "copy_to", "format", "locale", "ignore_malformed", "fields"},
+	"boolean":            {"index", "store", "doc_values", "null_value", "fields"},
+	"ip":                 {"index", "store", "doc_values", "null_value", "copy_to", "ignore_malformed", "fields"},
+	"geo_point":          {"index", "store", "doc_values", "null_value", "copy_to", "ignore_malformed", "ignore_z_value"},
+	"geo_shape":          {"index", "doc_values", "orientation", "coerce", "ignore_malformed", "ignore_z_value"},
+	"nested":             {"dynamic", "properties", "include_in_parent", "include_in_root"},
+	"object":             {"dynamic", "enabled", "properties"},
+	"join":               {"relations", "eager_global_ordinals"},
+	"dense_vector":       {"dims", "index", "similarity", "index_options"},
+	"sparse_vector":      {},
+	"flattened":          {"index", "doc_values", "null_value", "ignore_above", "depth_limit", "eager_global_ordinals", "split_queries_on_whitespace", "similarity"},
+	"completion":         {"analyzer", "search_analyzer", "preserve_separators", "preserve_position_increments", "max_input_length", "contexts"},
+	"search_as_you_type": {"analyzer", "search_analyzer", "max_shingle_size", "index", "index_options", "norms", "store", "similarity", "term_vector"},
+}
+
+var commonFieldMappingParams = []string{"meta", "boost"}
+
+func newFieldMapping(fieldType string) *fieldMapping {
+	return &fieldMapping{fieldType: fieldType, params: make(map[string]interface{})}
+}
+
+// NewKeywordField creates a keyword field mapping.
+func NewKeywordField() *fieldMapping { return newFieldMapping("keyword") }
+
+// NewTextField creates a text field mapping.
+func NewTextField() *fieldMapping { return newFieldMapping("text") }
+
+// NewNumericField creates a numeric field mapping of type long, integer,
+// short, byte, double, float, half_float, scaled_float or unsigned_long.
+func NewNumericField(numericType string) *fieldMapping { return newFieldMapping(numericType) }
+
+// NewScaledFloatField creates a scaled_float field mapping.
+func NewScaledFloatField(scalingFactor float64) *fieldMapping {
+	f := newFieldMapping("scaled_float")
+	f.params["scaling_factor"] = scalingFactor
+	return f
+}
+
+// NewDateField creates a date field mapping.
+func NewDateField() *fieldMapping { return newFieldMapping("date") }
+
+// NewBooleanField creates a boolean field mapping.
+func NewBooleanField() *fieldMapping { return newFieldMapping("boolean") }
+
+// NewIpField creates an ip field mapping.
+func NewIpField() *fieldMapping { return newFieldMapping("ip") }
+
+// NewGeoPointField creates a geo_point field mapping.
+func NewGeoPointField() *fieldMapping { return newFieldMapping("geo_point") }
+
+// NewGeoShapeField creates a geo_shape field mapping.
+func NewGeoShapeField() *fieldMapping { return newFieldMapping("geo_shape") }
+
+// NewNestedField creates a nested field mapping.
+func NewNestedField() *fieldMapping { return newFieldMapping("nested") }
+
+// NewObjectField creates an object field mapping.
+func NewObjectField() *fieldMapping { return newFieldMapping("object") }
+
+// NewJoinField creates a join field mapping, see Relation.
+func NewJoinField() *fieldMapping { return newFieldMapping("join") }
+
+// NewDenseVectorField creates a dense_vector field mapping with dims dimensions.
+func NewDenseVectorField(dims int) *fieldMapping {
+	f := newFieldMapping("dense_vector")
+	f.params["dims"] = dims
+	return f
+}
+
+// NewSparseVectorField creates a sparse_vector field mapping.
+func NewSparseVectorField() *fieldMapping { return newFieldMapping("sparse_vector") }
+
+// NewFlattenedField creates a flattened field mapping.
+func NewFlattenedField() *fieldMapping { return newFieldMapping("flattened") }
+
+// NewCompletionField creates a completion field mapping.
+func NewCompletionField() *fieldMapping { return newFieldMapping("completion") }
+
+// NewFieldMapping creates a field mapping of any type, e.g. a type provided
+// by a plugin. Parameters are not validated for unknown types.
+func NewFieldMapping(fieldType string) *fieldMapping { return newFieldMapping(fieldType) }
+
+// Type returns the field type.
+func (f *fieldMapping) Type() string {
+	return f.fieldType
+}
+
+// Param sets a mapping parameter.
+func (f *fieldMapping) Param(name string, value interface{}) *fieldMapping {
+	f.params[name] = value
+	return f
+}
+
+// Index sets whether the field is searchable.
+func (f *fieldMapping) Index(index bool) *fieldMapping { return f.Param("index", index) }
+
+// Store stores the field value apart from _source.
+func (f *fieldMapping) Store(store bool) *fieldMapping { return f.Param("store", store) }
+
+// DocValues sets whether doc values are stored for sorting and aggregations.
+func (f *fieldMapping) DocValues(docValues bool) *fieldMapping {
+	return f.Param("doc_values", docValues)
+}
+
+// NullValue replaces explicit null values.
+func (f *fieldMapping) NullValue(value interface{}) *fieldMapping {
+	return f.Param("null_value", value)
+}
+
+// CopyTo copies the field value into other fields.
+func (f *fieldMapping) CopyTo(fields ...string) *fieldMapping { return f.Param("copy_to", fields) }
+
+// Analyzer sets the index time analyzer of a text field.
+func (f *fieldMapping) Analyzer(analyzer string) *fieldMapping {
+	return f.Param("analyzer", analyzer)
+}
+
+// SearchAnalyzer sets the search time analyzer of a text field.
+func (f *fieldMapping) SearchAnalyzer(analyzer string) *fieldMapping {
+	return f.Param("search_analyzer", analyzer)
+}
+
+// Fielddata enables in-memory fielddata on a text field.
+func (f *fieldMapping) Fielddata(fielddata bool) *fieldMapping {
+	return f.Param("fielddata", fielddata)
+}
+
+// Normalizer sets the normalizer of a keyword field.
+func (f *fieldMapping) Normalizer(normalizer string) *fieldMapping {
+	return f.Param("normalizer", normalizer)
+}
+
+// IgnoreAbove does not index strings longer than ignoreAbove.
+func (f *fieldMapping) IgnoreAbove(ignoreAbove int) *fieldMapping {
+	return f.Param("ignore_above", ignoreAbove)
+}
+
+// Format sets the date formats, e.g. "yyyy-MM-dd HH:mm:ss||epoch_millis".
+func (f *fieldMapping) Format(format string) *fieldMapping { return f.Param("format", format) }
+
+// Similarity sets the scoring algorithm of a text or keyword field, or
+// the vector similarity of a dense_vector field: l2_norm, dot_product,
+// cosine or max_inner_product.
+func (f *fieldMapping) Similarity(similarity string) *fieldMapping {
+	return f.Param("similarity", similarity)
+}
+
+// HnswIndexOptions sets the HNSW parameters of an indexed dense_vector field.
+func (f *fieldMapping) HnswIndexOptions(m, efConstruction int) *fieldMapping {
+	return f.Param("index_options", map[string]interface{}{
+		"type":            "hnsw",
+		"m":               m,
+		"ef_construction": efConstruction,
+	})
+}
+
+// Relation adds a parent/children relation to a join field.
+func (f *fieldMapping) Relation(parent string, children ...string) *fieldMapping {
+	relations, _ := f.params["relations"].(map[string]interface{})
+	if relations == nil {
+		relations = make(map[string]interface{})
+		f.params["relations"] = relations
+	}
+	if len(children) == 1 {
+		relations[parent] = children[0]
+	} else {
+		relations[parent] = children
+	}
+	return f
+}
+
+// Field adds a multi-field, e.g. a keyword sub-field of a text field.
+func (f *fieldMapping) Field(name string, field *fieldMapping) *fieldMapping {
+	if f.fields == nil {
+		f.fields = make(map[string]*fieldMapping)
+	}
+	f.fields[name] = field
+	return f
+}
+
+// Property adds a sub-field to an object or nested field.
+func (f *fieldMapping) Property(name string, field *fieldMapping) *fieldMapping {
+	if f.properties == nil {
+		f.properties = make(map[string]*fieldMapping)
+	}
+	f.properties[name] = field
+	return f
+}
+
+// Build returns the map for the field mapping.
+func (f *fieldMapping) Build() (interface{}, error) {
+	if f.fieldType == "" {
+		return nil, fmt.Errorf("mapping: field type must be set")
+	}
+	if allowed, ok := fieldMappingParams[f.fieldType]; ok {
+		names := make([]string, 0, len(f.params)+2)
+		for name := range f.params {
+			names = append(names, name)
+		}
+		if len(f.fields) > 0 {
+			names = append(names, "fields")
+		}
+		if len(f.properties) > 0 {
+			names = append(names, "properties")
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !containsString(allowed, name) && !containsString(commonFieldMappingParams, name) {
+				return nil, fmt.Errorf("%s does not support parameter %s", f.fieldType, name)
+			}
+		}
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	source := make(map[string]interface{}, len(f.params)+3)
+	if f.fieldType != "object" || len(f.properties) == 0 {
+		source["type"] = f.fieldType
+	}
+	for name, value := range f.params {
+		source[name] = value
+	}
+	if len(f.fields) > 0 {
+		fields, err := buildProperties(f.fields)
+		if err != nil {
+			return nil, err
+		}
+		source["fields"] = fields
+	}
+	if len(f.properties) > 0 {
+		props, err := buildProperties(f.properties)
+		if err != nil {
+			return nil, err
+		}
+		source["properties"] = props
+	}
+	return source, nil
+}
+
+func (f *fieldMapping) validate() error {
+	switch f.fieldType {
+	case "dense_vector":
+		dims, ok := intParam(f.params["dims"])
+		if !ok || dims <= 0 || dims > 4096 {
+			return fmt.Errorf("dense_vector dims must be in [1, 4096]")
+		}
+		if similarity, ok := f.params["similarity"]; ok {
+			switch similarity {
+			case "l2_norm", "dot_product", "cosine", "max_inner_product":
+			default:
+				return fmt.Errorf("dense_vector: invalid similarity %v", similarity)
+			}
+		}
+	case "scaled_float":
+		if _, ok := f.params["scaling_factor"]; !ok {
+			return fmt.Errorf("scaled_float requires scaling_factor")
+		}
+	case "join":
+		if _, ok := f.params["relations"]; !ok {
+			return fmt.Errorf("join requires at least one relation")
+		}
+	}
+	return nil
+}
+
+// intParam returns a numeric parameter value as an int64, whatever Go
+// number type it was given as. ok is false for non-integral values.
+func intParam(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case float32:
+		return int64(v), float32(int64(v)) == v
+	case float64:
+		return int64(v), float64(int64(v)) == v
+	}
+	return 0, false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/dynamic-templates.html
+type dynamicTemplate struct {
+	name             string
+	matchMappingType string
+	match            string
+	unmatch          string
+	pathMatch        string
+	pathUnmatch      string
+	matchPattern     string
+	mapping          *fieldMapping
+}
+
+// NewDynamicTemplate creates and initializes a new dynamicTemplate.
+func NewDynamicTemplate(name string) *dynamicTemplate {
+	return &dynamicTemplate{name: name}
+}
+
+// MatchMappingType matches the JSON type detected, e.g. string or long.
+func (t *dynamicTemplate) MatchMappingType(matchMappingType string) *dynamicTemplate {
+	t.matchMappingType = matchMappingType
+	return t
+}
+
+// Match matches the field name.
+func (t *dynamicTemplate) Match(match string) *dynamicTemplate {
+	t.match = match
+	return t
+}
+
+// Unmatch excludes field names.
+func (t *dynamicTemplate) Unmatch(unmatch string) *dynamicTemplate {
+	t.unmatch = unmatch
+	return t
+}
+
+// PathMatch matches the full dotted path of the field.
+func (t *dynamicTemplate) PathMatch(pathMatch string) *dynamicTemplate {
+	t.pathMatch = pathMatch
+	return t
+}
+
+// PathUnmatch excludes full dotted paths.
+func (t *dynamicTemplate) PathUnmatch(pathUnmatch string) *dynamicTemplate {
+	t.pathUnmatch = pathUnmatch
+	return t
+}
+
+// MatchPattern is simple (default) or regex.
+func (t *dynamicTemplate) MatchPattern(matchPattern string) *dynamicTemplate {
+	t.matchPattern = matchPattern
+	return t
+}
+
+// Mapping sets the mapping of matching fields.
+func (t *dynamicTemplate) Mapping(mapping *fieldMapping) *dynamicTemplate {
+	t.mapping = mapping
+	return t
+}
+
+func (t *dynamicTemplate) Build() (interface{}, error) {
+	if t.name == "" || t.mapping == nil {
+		return nil, fmt.Errorf("dynamic template: name and mapping must be set")
+	}
+	if t.matchMappingType == "" && t.match == "" && t.pathMatch == "" {
+		return nil, fmt.Errorf("dynamic template %s: match_mapping_type, match or path_match must be set", t.name)
+	}
+	mapping, err := t.mapping.Build()
+	if err != nil {
+		return nil, fmt.Errorf("dynamic template %s: %v", t.name, err)
+	}
+	params := map[string]interface{}{
+		"mapping": mapping,
+	}
+	if t.matchMappingType != "" {
+		params["match_mapping_type"] = t.matchMappingType
+	}
+	if t.match != "" {
+		params["match"] = t.match
+	}
+	if t.unmatch != "" {
+		params["unmatch"] = t.unmatch
+	}
+	if t.pathMatch != "" {
+		params["path_match"] = t.pathMatch
+	}
+	if t.pathUnmatch != "" {
+		params["path_unmatch"] = t.pathUnmatch
+	}
+	if t.matchPattern != "" {
+		params["match_pattern"] = t.matchPattern
+	}
+	return map[string]interface{}{
+		t.name: params,
+	}, nil
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/index-modules.html
+type indexSettings struct {
+	settings     map[string]interface{}
+	analysisItem query
+}
+
+// NewIndexSettings creates and initializes a new indexSettings.
+func NewIndexSettings() *indexSettings {
+	return &indexSettings{settings: make(map[string]interface{})}
+}
+
+// NumberOfShards sets the number of primary shards.
+func (s *indexSettings) NumberOfShards(shards int) *indexSettings {
+	return s.Set("number_of_shards", shards)
+}
+
+// NumberOfReplicas sets the number of replicas of each primary shard.
+func (s *indexSettings) NumberOfReplicas(replicas int) *indexSettings {
+	return s.Set("number_of_replicas", replicas)
+}
+
+// RefreshInterval sets how often the index is refreshed, e.g. "1s" or "-1".
+func (s *indexSettings) RefreshInterval(interval string) *indexSettings {
+	return s.Set("refresh_interval", interval)
+}
+
+// Analysis sets the analysis section.
+func (s *indexSettings) Analysis(analysis query) *indexSettings {
+	s.analysisItem = analysis
+	return s
+}
+
+// Set sets any index setting, without the "index." prefix.
+func (s *indexSettings) Set(name string, value interface{}) *indexSettings {
+	s.settings[name] = value
+	return s
+}
+
+// Build returns the map for the settings section.
+func (s *indexSettings) Build() (interface{}, error) {
+	index := make(map[string]interface{}, len(s.settings))
+	for name, value := range s.settings {
+		index[name] = value
+	}
+	if shards, ok := intParam(index["number_of_shards"]); ok && shards < 1 {
+		return nil, fmt.Errorf("settings: number_of_shards must be at least 1")
+	}
+	if replicas, ok := intParam(index["number_of_replicas"]); ok && replicas < 0 {
+		return nil, fmt.Errorf("settings: number_of_replicas can not be negative")
+	}
+	source := map[string]interface{}{
+		"index": index,
+	}
+	if s.analysisItem != nil {
+		src, err := s.analysisItem.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["analysis"] = src
+	}
+	return source, nil
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/indices-create-index.html
+type createIndex struct {
+	name     string
+	settings *indexSettings
+	mappings *mapping
+	aliases  []*alias
+}
+
+// NewCreateIndex creates and initializes a new createIndex request.
+func NewCreateIndex(name string) *createIndex {
+	return &createIndex{name: name}
+}
+
+// Settings sets the index settings.
+func (c *createIndex) Settings(settings *indexSettings) *createIndex {
+	c.settings = settings
+	return c
+}
+
+// Mappings sets the index mapping.
+func (c *createIndex) Mappings(mappings *mapping) *createIndex {
+	c.mappings = mappings
+	return c
+}
+
+// Alias adds aliases created with the index.
+func (c *createIndex) Alias(aliases ...*alias) *createIndex {
+	c.aliases = append(c.aliases, aliases...)
+	return c
+}
+
+// Build returns the body of the PUT /index request.
+func (c *createIndex) Build() (interface{}, error) {
+	if c.name == "" {
+		return nil, fmt.Errorf("create index: name must be set")
+	}
+	source := make(map[string]interface{})
+	if c.settings != nil {
+		src, err := c.settings.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["settings"] = src
+	}
+	if c.mappings != nil {
+		src, err := c.mappings.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["mappings"] = src
+	}
+	if len(c.aliases) > 0 {
+		src, err := buildAliases(c.aliases)
+		if err != nil {
+			return nil, err
+		}
+		source["aliases"] = src
+	}
+	return source, nil
+}
+
+// Do sends the create index request.
+func (c *createIndex) Do(ctx context.Context, t Transport) error {
+	src, err := c.Build()
+	if err != nil {
+		return err
+	}
+	_, err = perform(ctx, t, "PUT", url.PathEscape(c.name), src)
+	return err
+}
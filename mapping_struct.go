@@ -0,0 +1,259 @@
+package esbuilder
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MappingConflicts lists struct fields whose Go type does not fit the
+// declared Elasticsearch type.
+type MappingConflicts []error
+
+func (c MappingConflicts) Error() string {
+	msgs := make([]string, 0, len(c))
+	for _, err := range c {
+		msgs = append(msgs, err.Error())
+	}
+	return "mapping conflicts: " + strings.Join(msgs, "; ")
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// NewMappingFromStruct derives a mapping from the exported fields of the
+// struct v. Field names follow the json tag. The es tag declares the
+// field type followed by mapping parameters, e.g.
+//
+//	Title   string    `json:"title" es:"text,analyzer=ik_max_word"`
+//	Vector  []float32 `json:"vector" es:"dense_vector,dims=768"`
+//	Ignored string    `es:"-"`
+//
+// Fields without an es tag get the type Elasticsearch would detect
+// dynamically. Embedded structs are flattened, structs become objects
+// unless declared nested. If some declared types conflict with the Go
+// types, the mapping is returned together with a MappingConflicts error.
+func NewMappingFromStruct(v interface{}) (*mapping, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("mapping: %T is not a struct", v)
+	}
+	g := &mappingGenerator{visiting: make(map[reflect.Type]bool)}
+	m := NewMapping()
+	m.properties = g.properties(t, "")
+	if len(g.conflicts) > 0 {
+		return m, g.conflicts
+	}
+	return m, nil
+}
+
+type mappingGenerator struct {
+	visiting  map[reflect.Type]bool
+	conflicts MappingConflicts
+}
+
+func (g *mappingGenerator) properties(t reflect.Type, prefix string) map[string]*fieldMapping {
+	props := make(map[string]*fieldMapping)
+	if g.visiting[t] {
+		g.conflicts = append(g.conflicts, fmt.Errorf("%s: recursive type %s", strings.TrimSuffix(prefix, "."), t))
+		return props
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("es")
+		if tag == "-" {
+			continue
+		}
+		name, skip := jsonFieldName(sf)
+		if skip {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			et := sf.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct && et != timeType {
+				for n, f := range g.properties(et, prefix) {
+					props[n] = f
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if f := g.field(sf.Type, tag, prefix+name); f != nil {
+			props[name] = f
+		}
+	}
+	return props
+}
+
+// jsonFieldName returns the name given by the json tag, and whether the
+// field is skipped.
+func jsonFieldName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name := strings.Split(tag, ",")[0]
+	return name, false
+}
+
+func (g *mappingGenerator) field(t reflect.Type, tag, path string) *fieldMapping {
+	elem := t
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	whole := elem
+	if (elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array) && elem.Elem().Kind() != reflect.Uint8 {
+		elem = elem.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+	}
+
+	if tag == "" {
+		return g.inferField(elem, path)
+	}
+
+	parts := strings.Split(tag, ",")
+	fieldType := strings.TrimSpace(parts[0])
+	f := NewFieldMapping(fieldType)
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if key == "" {
+			continue
+		}
+		if len(kv) == 1 {
+			f.Param(key, true)
+			continue
+		}
+		f.Param(key, parseTagValue(strings.TrimSpace(kv[1])))
+	}
+
+	// A vector, and a geo_point given as [lon, lat], is a single value
+	// encoded as an array, so the slice type itself is checked.
+	checked := elem
+	if fieldType == "dense_vector" || (fieldType == "geo_point" && goTypeFits(fieldType, whole)) {
+		checked = whole
+	}
+	if fieldType == "dense_vector" {
+		if dims, ok := intParam(f.params["dims"]); ok && checked.Kind() == reflect.Array && int64(checked.Len()) != dims {
+			g.conflicts = append(g.conflicts, fmt.Errorf("%s: dense_vector dims=%d but array has %d elements", path, dims, checked.Len()))
+		}
+	}
+	if !goTypeFits(fieldType, checked) {
+		g.conflicts = append(g.conflicts, fmt.Errorf("%s: Go type %s conflicts with %s", path, t, fieldType))
+	}
+	if (fieldType == "object" || fieldType == "nested") && elem.Kind() == reflect.Struct {
+		f.properties = g.properties(elem, path+".")
+	}
+	return f
+}
+
+func (g *mappingGenerator) inferField(t reflect.Type, path string) *fieldMapping {
+	if t == timeType {
+		return NewDateField()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return NewTextField().Field("keyword", NewKeywordField().IgnoreAbove(256))
+	case reflect.Bool:
+		return NewBooleanField()
+	case reflect.Int, reflect.Int64, reflect.Uint32, reflect.Uint:
+		return NewNumericField("long")
+	case reflect.Int32, reflect.Uint16:
+		return NewNumericField("integer")
+	case reflect.Int16, reflect.Uint8:
+		return NewNumericField("short")
+	case reflect.Int8:
+		return NewNumericField("byte")
+	case reflect.Uint64:
+		return NewNumericField("unsigned_long")
+	case reflect.Float64:
+		return NewNumericField("double")
+	case reflect.Float32:
+		return NewNumericField("float")
+	case reflect.Slice:
+		// []byte is encoded as a base64 string.
+		return NewFieldMapping("binary")
+	case reflect.Struct:
+		f := NewObjectField()
+		f.properties = g.properties(t, path+".")
+		return f
+	case reflect.Map:
+		return NewObjectField()
+	}
+	// interface{} and other kinds are left to dynamic mapping.
+	return nil
+}
+
+func parseTagValue(value string) interface{} {
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	return value
+}
+
+// goTypeFits reports whether values of Go type t can be indexed into a
+// field of fieldType. Unknown field types always fit.
+func goTypeFits(fieldType string, t reflect.Type) bool {
+	kind := t.Kind()
+	isInt := kind >= reflect.Int && kind <= reflect.Uint64
+	isFloat := kind == reflect.Float32 || kind == reflect.Float64
+	isStruct := kind == reflect.Struct && t != timeType
+	switch fieldType {
+	case "keyword", "constant_keyword":
+		// Numeric identifiers are commonly indexed as keywords.
+		return kind == reflect.String || isInt
+	case "text", "wildcard", "completion", "search_as_you_type", "ip", "match_only_text":
+		return kind == reflect.String
+	case "long", "integer", "short", "byte", "unsigned_long":
+		return isInt
+	case "double", "float", "half_float", "scaled_float":
+		return isInt || isFloat
+	case "boolean":
+		return kind == reflect.Bool
+	case "date", "date_nanos":
+		return t == timeType || kind == reflect.String || isInt
+	case "geo_point":
+		return isStruct || kind == reflect.String || kind == reflect.Map ||
+			(kind == reflect.Slice || kind == reflect.Array) && isFloatKind(t.Elem().Kind())
+	case "geo_shape":
+		return isStruct || kind == reflect.String || kind == reflect.Map
+	case "dense_vector":
+		return (kind == reflect.Slice || kind == reflect.Array) && isFloatKind(t.Elem().Kind())
+	case "sparse_vector", "rank_features":
+		return kind == reflect.Map
+	case "object", "nested", "flattened":
+		return isStruct || kind == reflect.Map
+	case "join":
+		return kind == reflect.String || isStruct || kind == reflect.Map
+	case "binary":
+		return kind == reflect.String || kind == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+	}
+	return true
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
@@ -0,0 +1,150 @@
+package esbuilder
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jsonEqual reports whether src marshals to the same JSON value as want.
+func jsonEqual(t *testing.T, src interface{}, want string) bool {
+	t.Helper()
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, expected interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(want), &expected); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got  %s\nwant %s", data, want)
+		return false
+	}
+	return true
+}
+
+type testBase struct {
+	Id string `json:"id" es:"keyword"`
+}
+
+type testAuthor struct {
+	Name string `json:"name" es:"keyword"`
+}
+
+type testDoc struct {
+	testBase
+	Title     string            `json:"title" es:"text,analyzer=english"`
+	Views     int64             `json:"views"`
+	Rating    float32           `json:"rating"`
+	Published time.Time         `json:"published"`
+	Draft     bool              `json:"draft"`
+	Tags      []string          `json:"tags" es:"keyword"`
+	Author    testAuthor        `json:"author"`
+	Comments  []testAuthor      `json:"comments" es:"nested"`
+	Vector    [3]float32        `json:"vector" es:"dense_vector,dims=3"`
+	Location  []float64         `json:"location" es:"geo_point"`
+	Attrs     map[string]string `json:"attrs"`
+	Raw       []byte            `json:"raw"`
+	Ignored   string            `json:"ignored" es:"-"`
+	Skipped   string            `json:"-"`
+	Any       interface{}       `json:"any"`
+	hidden    string
+}
+
+func TestNewMappingFromStruct(t *testing.T) {
+	m, err := NewMappingFromStruct(&testDoc{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := m.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonEqual(t, src, `{"properties":{
+		"id":{"type":"keyword"},
+		"title":{"type":"text","analyzer":"english"},
+		"views":{"type":"long"},
+		"rating":{"type":"float"},
+		"published":{"type":"date"},
+		"draft":{"type":"boolean"},
+		"tags":{"type":"keyword"},
+		"author":{"properties":{"name":{"type":"keyword"}}},
+		"comments":{"type":"nested","properties":{"name":{"type":"keyword"}}},
+		"vector":{"type":"dense_vector","dims":3},
+		"location":{"type":"geo_point"},
+		"attrs":{"type":"object"},
+		"raw":{"type":"binary"}
+	}}`)
+}
+
+func TestNewMappingFromStructConflicts(t *testing.T) {
+	type recursive struct {
+		Child *recursive `json:"child"`
+	}
+	tests := []struct {
+		name string
+		v    interface{}
+		err  string
+	}{
+		{"not a struct", 1, "is not a struct"},
+		{"text on int", struct {
+			A int `json:"a" es:"text"`
+		}{}, "a: Go type int conflicts with text"},
+		{"long on string", struct {
+			A string `json:"a" es:"long"`
+		}{}, "conflicts with long"},
+		{"geo_point on bools", struct {
+			A []bool `json:"a" es:"geo_point"`
+		}{}, "conflicts with geo_point"},
+		{"dense_vector on strings", struct {
+			A []string `json:"a" es:"dense_vector,dims=2"`
+		}{}, "conflicts with dense_vector"},
+		{"dense_vector dims", struct {
+			A [2]float32 `json:"a" es:"dense_vector,dims=3"`
+		}{}, "dims=3 but array has 2 elements"},
+		{"recursive", recursive{}, "recursive type"},
+	}
+	for _, tt := range tests {
+		_, err := NewMappingFromStruct(tt.v)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestNewMappingFromStructFits(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"numeric keyword", struct {
+			A int `json:"a" es:"keyword"`
+		}{}},
+		{"int as double", struct {
+			A int `json:"a" es:"double"`
+		}{}},
+		{"date string", struct {
+			A string `json:"a" es:"date"`
+		}{}},
+		{"geo_point list", struct {
+			A [][]float64 `json:"a" es:"geo_point"`
+		}{}},
+		{"geo_point string", struct {
+			A string `json:"a" es:"geo_point"`
+		}{}},
+		{"pointer", struct {
+			A *int64 `json:"a" es:"long"`
+		}{}},
+	}
+	for _, tt := range tests {
+		if _, err := NewMappingFromStruct(tt.v); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var indexVersionRe = regexp.MustCompile(`_v(\d+)$`)
+
+// indexMigration moves an alias to a new index without read downtime: the
+// new versioned index is created, filled with a reindex and verified
+// against the current index before the alias is swapped atomically and the
+// old index retired. Writes to the old index are rejected while they are
+// blocked, so no document is lost: by default from the start of the
+// reindex until the alias points to the new index. With a CatchUpQuery the
+// reindex runs with writes open and they are only blocked for a second,
+// short reindex of the documents changed in the meantime. A failure before
+// the swap leaves the alias untouched, clears the write block and keeps
+// the new index in place for inspection.
+type indexMigration struct {
+	t               Transport
+	alias           string
+	oldIndex        string
+	newIndex        string
+	template        *indexTemplate
+	settings        *indexSettings
+	mappings        *mapping
+	reindexScript   *script
+	reindexPipeline string
+	catchUpQuery    query
+	pollInterval    time.Duration
+	verifyQueries   map[string]query
+	sampleSize      int64
+	retire          string
+	dryRun          bool
+}
+
+// NewIndexMigration creates a migration of alias using t.
+func NewIndexMigration(t Transport, alias string) *indexMigration {
+	return &indexMigration{
+		t:             t,
+		alias:         alias,
+		pollInterval:  5 * time.Second,
+		verifyQueries: make(map[string]query),
+		retire:        "close",
+	}
+}
+
+// OldIndex sets the index currently behind the alias instead of looking
+// it up.
+func (m *indexMigration) OldIndex(index string) *indexMigration {
+	m.oldIndex = index
+	return m
+}
+
+// NewIndex names the new index. By default the version suffix of the old
+// index is incremented, e.g. products_v2 to products_v3, starting at
+// <alias>_v1.
+func (m *indexMigration) NewIndex(index string) *indexMigration {
+	m.newIndex = index
+	return m
+}
+
+// Template installs the index template before the new index is created.
+// Its index_patterns must match the new index.
+func (m *indexMigration) Template(template *indexTemplate) *indexMigration {
+	m.template = template
+	return m
+}
+
+// Settings sets the settings the new index is created with.
+func (m *indexMigration) Settings(settings *indexSettings) *indexMigration {
+	m.settings = settings
+	return m
+}
+
+// Mappings sets the mapping the new index is created with.
+func (m *indexMigration) Mappings(mappings *mapping) *indexMigration {
+	m.mappings = mappings
+	return m
+}
+
+// ReindexScript sets the script applied to documents while reindexing.
+func (m *indexMigration) ReindexScript(script *script) *indexMigration {
+	m.reindexScript = script
+	return m
+}
+
+// ReindexPipeline sets the ingest pipeline documents go through while
+// reindexing.
+func (m *indexMigration) ReindexPipeline(pipeline string) *indexMigration {
+	m.reindexPipeline = pipeline
+	return m
+}
+
+// CatchUpQuery keeps the old index writable during the reindex. Writes
+// are then blocked and the documents matching q, e.g. a range query on an
+// update timestamp covering the reindex, are copied again before the
+// verification. Deletions in the meantime are not copied and make the
+// verification fail.
+func (m *indexMigration) CatchUpQuery(q query) *indexMigration {
+	m.catchUpQuery = q
+	return m
+}
+
+// PollInterval sets how often the reindex task is polled, 5s by default.
+func (m *indexMigration) PollInterval(interval time.Duration) *indexMigration {
+	m.pollInterval = interval
+	return m
+}
+
+// VerifyQuery adds a query that must return the same total on both
+// indices, and the same top documents if SampleSize is set.
+func (m *indexMigration) VerifyQuery(name string, q query) *indexMigration {
+	m.verifyQueries[name] = q
+	return m
+}
+
+// SampleSize is the number of top documents compared per verify query, 0
+// by default. Scores depend on the shard layout and ties are broken
+// arbitrarily, so only enable it for queries with unambiguous top
+// documents.
+func (m *indexMigration) SampleSize(size int64) *indexMigration {
+	m.sampleSize = size
+	return m
+}
+
+// Retire sets what happens to the old index after the swap: close
+// (default), delete, which removes it in the same atomic alias request,
+// or keep, which leaves it open and write blocked.
+func (m *indexMigration) Retire(retire string) *indexMigration {
+	m.retire = retire
+	return m
+}
+
+// DryRun prints every request to w instead of sending it. GET requests
+// still reach the cluster to resolve the alias. Verification is skipped.
+func (m *indexMigration) DryRun(w io.Writer) *indexMigration {
+	m.t = NewDryRunTransport(w, m.t)
+	m.dryRun = true
+	return m
+}
+
+// indexMigrationResult reports a completed migration.
+type indexMigrationResult struct {
+	OldIndex string
+	NewIndex string
+	OldCount int64
+	NewCount int64
+	Reindex  *byQueryResponse
+	CatchUp  *byQueryResponse
+}
+
+// Do runs the migration.
+func (m *indexMigration) Do(ctx context.Context) (*indexMigrationResult, error) {
+	if m.alias == "" {
+		return nil, fmt.Errorf("migration: alias must be set")
+	}
+	switch m.retire {
+	case "close", "delete", "keep":
+	default:
+		return nil, fmt.Errorf("migration: retire must be close, delete or keep")
+	}
+	result := &indexMigrationResult{OldIndex: m.oldIndex, NewIndex: m.newIndex}
+	if result.OldIndex == "" {
+		indices, err := GetAliasIndices(ctx, m.t, m.alias)
+		if err != nil {
+			return nil, fmt.Errorf("migration: resolve alias %s: %v", m.alias, err)
+		}
+		if len(indices) > 1 {
+			return nil, fmt.Errorf("migration: alias %s points to %d indices %v", m.alias, len(indices), indices)
+		}
+		if len(indices) == 1 {
+			result.OldIndex = indices[0]
+		}
+	}
+	if result.NewIndex == "" {
+		result.NewIndex = NextIndexVersion(m.alias, result.OldIndex)
+	}
+	if result.NewIndex == result.OldIndex {
+		return nil, fmt.Errorf("migration: new index %s is the current index", result.NewIndex)
+	}
+
+	err := m.create(ctx, result.NewIndex)
+	if err != nil {
+		return result, err
+	}
+	if result.OldIndex != "" {
+		if m.catchUpQuery != nil {
+			if result.Reindex, err = m.reindex(ctx, result, nil); err != nil {
+				return result, err
+			}
+		}
+		if err := m.writeBlock(ctx, result.OldIndex, true); err != nil {
+			return result, err
+		}
+		if m.catchUpQuery != nil {
+			result.CatchUp, err = m.reindex(ctx, result, m.catchUpQuery)
+		} else {
+			result.Reindex, err = m.reindex(ctx, result, nil)
+		}
+		if err != nil {
+			return result, m.clearWriteBlock(ctx, result.OldIndex, err)
+		}
+		if err := m.verify(ctx, result); err != nil {
+			return result, m.clearWriteBlock(ctx, result.OldIndex, err)
+		}
+	}
+	if err := m.swap(ctx, result); err != nil {
+		if result.OldIndex != "" {
+			err = m.clearWriteBlock(ctx, result.OldIndex, err)
+		}
+		return result, err
+	}
+	if result.OldIndex != "" && m.retire == "close" {
+		if _, err := perform(ctx, m.t, "POST", url.PathEscape(result.OldIndex)+"/_close", nil); err != nil {
+			return result, fmt.Errorf("migration: close %s: %v", result.OldIndex, err)
+		}
+	}
+	return result, nil
+}
+
+// writeBlock sets or removes the index.blocks.write setting of index.
+func (m *indexMigration) writeBlock(ctx context.Context, index string, block bool) error {
+	body := map[string]interface{}{"index.blocks.write": block}
+	if _, err := perform(ctx, m.t, "PUT", url.PathEscape(index)+"/_settings", body); err != nil {
+		return fmt.Errorf("migration: set write block of %s to %t: %v", index, block, err)
+	}
+	return nil
+}
+
+// clearWriteBlock reopens index for writes after the migration failed with
+// cause, even if ctx was canceled, and returns cause.
+func (m *indexMigration) clearWriteBlock(ctx context.Context, index string, cause error) error {
+	if err := m.writeBlock(context.WithoutCancel(ctx), index, false); err != nil {
+		return fmt.Errorf("%v; %v", cause, err)
+	}
+	return cause
+}
+
+func (m *indexMigration) create(ctx context.Context, index string) error {
+	if m.template != nil {
+		matched := false
+		for _, pattern := range m.template.indexPatterns {
+			if ok, _ := path.Match(pattern, index); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("migration: index template %s does not match index %s", m.template.name, index)
+		}
+		if err := m.template.Do(ctx, m.t); err != nil {
+			return fmt.Errorf("migration: put index template: %v", err)
+		}
+	}
+	create := NewCreateIndex(index).Settings(m.settings).Mappings(m.mappings)
+	if err := create.Do(ctx, m.t); err != nil {
+		return fmt.Errorf("migration: create index %s: %v", index, err)
+	}
+	return nil
+}
+
+// reindex copies the documents of the old index matching q, all of them
+// if q is nil, to the new index and refreshes it.
+func (m *indexMigration) reindex(ctx context.Context, result *indexMigrationResult, q query) (*byQueryResponse, error) {
+	r := NewReindex(result.OldIndex).Dest(result.NewIndex).Script(m.reindexScript).
+		DestPipeline(m.reindexPipeline).WaitForCompletion(false)
+	if q != nil {
+		r.SourceQuery(q)
+	}
+	resp, err := r.Do(ctx, m.t)
+	if err != nil {
+		return nil, fmt.Errorf("migration: reindex: %v", err)
+	}
+	if resp.Task != "" {
+		if resp, err = WaitForTask(ctx, m.t, resp.Task, m.pollInterval, nil); err != nil {
+			return nil, fmt.Errorf("migration: reindex: %v", err)
+		}
+	}
+	if _, err := perform(ctx, m.t, "POST", url.PathEscape(result.NewIndex)+"/_refresh", nil); err != nil {
+		return nil, fmt.Errorf("migration: refresh %s: %v", result.NewIndex, err)
+	}
+	return resp, nil
+}
+
+func (m *indexMigration) verify(ctx context.Context, result *indexMigrationResult) error {
+	var err error
+	if result.OldCount, err = Count(ctx, m.t, result.OldIndex, nil); err != nil {
+		return fmt.Errorf("migration: count %s: %v", result.OldIndex, err)
+	}
+	if result.NewCount, err = Count(ctx, m.t, result.NewIndex, nil); err != nil {
+		return fmt.Errorf("migration: count %s: %v", result.NewIndex, err)
+	}
+	if !m.dryRun && result.OldCount != result.NewCount {
+		return fmt.Errorf("migration: %s has %d documents, %s has %d", result.OldIndex, result.OldCount, result.NewIndex, result.NewCount)
+	}
+	names := make([]string, 0, len(m.verifyQueries))
+	for name := range m.verifyQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		oldTotal, oldIds, err := m.sample(ctx, result.OldIndex, m.verifyQueries[name])
+		if err != nil {
+			return fmt.Errorf("migration: verify query %s on %s: %v", name, result.OldIndex, err)
+		}
+		newTotal, newIds, err := m.sample(ctx, result.NewIndex, m.verifyQueries[name])
+		if err != nil {
+			return fmt.Errorf("migration: verify query %s on %s: %v", name, result.NewIndex, err)
+		}
+		if m.dryRun {
+			continue
+		}
+		if oldTotal != newTotal {
+			return fmt.Errorf("migration: verify query %s matches %d documents on %s and %d on %s", name, oldTotal, result.OldIndex, newTotal, result.NewIndex)
+		}
+		if m.sampleSize <= 0 {
+			continue
+		}
+		for id := range oldIds {
+			if !newIds[id] {
+				return fmt.Errorf("migration: verify query %s: document %s of %s is not in the top %d of %s", name, id, result.OldIndex, m.sampleSize, result.NewIndex)
+			}
+		}
+	}
+	return nil
+}
+
+// sample returns the total and the _ids of the top documents of q on index.
+func (m *indexMigration) sample(ctx context.Context, index string, q query) (int64, map[string]bool, error) {
+	dsl := NewDsl()
+	dsl.SetQuery(q)
+	dsl.SetSize(m.sampleSize)
+	dsl.SetTrackTotal(true)
+	dsl.SetFetchSource(false)
+	resp, err := Search(ctx, m.t, index, dsl)
+	if err != nil {
+		return 0, nil, err
+	}
+	ids := make(map[string]bool)
+	if resp.Hits != nil {
+		for _, hit := range resp.Hits.Hits {
+			ids[hit.Id] = true
+		}
+	}
+	return resp.TotalHits(), ids, nil
+}
+
+func (m *indexMigration) swap(ctx context.Context, result *indexMigrationResult) error {
+	actions := NewAliases(NewAddAliasAction(result.NewIndex, NewAlias(m.alias)))
+	if result.OldIndex != "" {
+		if m.retire == "delete" {
+			actions.Add(NewRemoveIndexAction(result.OldIndex))
+		} else {
+			actions.Add(NewRemoveAliasAction(result.OldIndex, m.alias))
+		}
+	}
+	if err := actions.Do(ctx, m.t); err != nil {
+		return fmt.Errorf("migration: swap alias %s: %v", m.alias, err)
+	}
+	return nil
+}
+
+// NextIndexVersion returns the index following current for alias:
+// current with its _vN suffix incremented, or <alias>_v1.
+func NextIndexVersion(alias, current string) string {
+	if match := indexVersionRe.FindStringSubmatchIndex(current); match != nil {
+		version, err := strconv.Atoi(current[match[2]:match[3]])
+		if err == nil {
+			return current[:match[0]] + "_v" + strconv.Itoa(version+1)
+		}
+	}
+	return alias + "_v1"
+}
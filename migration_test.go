@@ -0,0 +1,228 @@
+package esbuilder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeCluster answers the requests of an index migration of the alias
+// products, currently pointing to products_v1. It records each request as
+// "METHOD path", without the query string, and the last body sent with it.
+// Searches return the hits of the index in hits.
+type fakeCluster struct {
+	counts   map[string]int
+	hits     map[string]string
+	requests []string
+	bodies   map[string][]byte
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{
+		counts: map[string]int{"products_v1": 3, "products_v2": 3},
+		hits:   map[string]string{"products_v1": `{"_id":"1"},{"_id":"2"}`, "products_v2": `{"_id":"1"},{"_id":"2"}`},
+		bodies: make(map[string][]byte),
+	}
+}
+
+func (c *fakeCluster) Perform(ctx context.Context, method, path string, body []byte) (int, []byte, error) {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	request := method + " " + path
+	c.requests = append(c.requests, request)
+	c.bodies[request] = body
+	index := strings.SplitN(path, "/", 2)[0]
+	switch {
+	case request == "GET _alias/products":
+		return 200, []byte(`{"products_v1":{"aliases":{"products":{}}}}`), nil
+	case request == "POST _reindex":
+		return 200, []byte(`{"task":"node:1"}`), nil
+	case request == "GET _tasks/node:1":
+		return 200, []byte(`{"completed":true,"task":{"node":"node","id":1},"response":{"total":3,"created":3,"failures":[]}}`), nil
+	case strings.HasSuffix(path, "/_count"):
+		return 200, []byte(`{"count":` + strconv.Itoa(c.counts[index]) + `}`), nil
+	case strings.HasSuffix(path, "/_search"):
+		return 200, []byte(`{"hits":{"total":{"value":2,"relation":"eq"},"hits":[` + c.hits[index] + `]}}`), nil
+	}
+	return 200, []byte(`{"acknowledged":true}`), nil
+}
+
+func newTestMigration(t Transport) *indexMigration {
+	template := NewIndexTemplate("products").IndexPatterns("products_v*")
+	mappings := NewMapping().Property("name", NewKeywordField())
+	return NewIndexMigration(t, "products").
+		Template(template).
+		Mappings(mappings).
+		PollInterval(0).
+		VerifyQuery("shoes", NewMatchQuery("name", "shoes"))
+}
+
+func TestIndexMigration(t *testing.T) {
+	cluster := newFakeCluster()
+	result, err := newTestMigration(cluster).Do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OldIndex != "products_v1" || result.NewIndex != "products_v2" {
+		t.Fatalf("migrated %s to %s", result.OldIndex, result.NewIndex)
+	}
+	want := []string{
+		"GET _alias/products",
+		"PUT _index_template/products",
+		"PUT products_v2",
+		"PUT products_v1/_settings",
+		"POST _reindex",
+		"GET _tasks/node:1",
+		"POST products_v2/_refresh",
+		"POST products_v1/_count",
+		"POST products_v2/_count",
+		"POST products_v1/_search",
+		"POST products_v2/_search",
+		"POST _aliases",
+		"POST products_v1/_close",
+	}
+	if !reflect.DeepEqual(cluster.requests, want) {
+		t.Fatalf("requests\n%s\nwant\n%s", strings.Join(cluster.requests, "\n"), strings.Join(want, "\n"))
+	}
+	if body := string(cluster.bodies["PUT products_v1/_settings"]); body != `{"index.blocks.write":true}` {
+		t.Errorf("write block body %s", body)
+	}
+	aliases := string(cluster.bodies["POST _aliases"])
+	if !strings.Contains(aliases, `"add"`) || !strings.Contains(aliases, `"remove"`) {
+		t.Errorf("aliases body %s", aliases)
+	}
+}
+
+func TestIndexMigrationCountMismatch(t *testing.T) {
+	cluster := newFakeCluster()
+	cluster.counts["products_v2"] = 2
+	_, err := newTestMigration(cluster).Do(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "has 3 documents") {
+		t.Fatalf("err = %v", err)
+	}
+	for _, request := range cluster.requests {
+		if request == "POST _aliases" || strings.HasSuffix(request, "/_close") {
+			t.Errorf("unexpected %s after a failed verification", request)
+		}
+	}
+	last := cluster.requests[len(cluster.requests)-1]
+	if last != "PUT products_v1/_settings" || string(cluster.bodies[last]) != `{"index.blocks.write":false}` {
+		t.Errorf("write block not cleared, last request %s %s", last, cluster.bodies[last])
+	}
+}
+
+func TestIndexMigrationCatchUp(t *testing.T) {
+	cluster := newFakeCluster()
+	result, err := newTestMigration(cluster).
+		CatchUpQuery(NewRangeQuery("updated").Gte("now-1h")).
+		Do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"GET _alias/products",
+		"PUT _index_template/products",
+		"PUT products_v2",
+		"POST _reindex",
+		"GET _tasks/node:1",
+		"POST products_v2/_refresh",
+		"PUT products_v1/_settings",
+		"POST _reindex",
+		"GET _tasks/node:1",
+		"POST products_v2/_refresh",
+		"POST products_v1/_count",
+		"POST products_v2/_count",
+		"POST products_v1/_search",
+		"POST products_v2/_search",
+		"POST _aliases",
+		"POST products_v1/_close",
+	}
+	if !reflect.DeepEqual(cluster.requests, want) {
+		t.Fatalf("requests\n%s\nwant\n%s", strings.Join(cluster.requests, "\n"), strings.Join(want, "\n"))
+	}
+	if body := string(cluster.bodies["POST _reindex"]); !strings.Contains(body, `"updated"`) {
+		t.Errorf("catch-up reindex body %s", body)
+	}
+	if result.Reindex == nil || result.CatchUp == nil {
+		t.Errorf("result %+v", result)
+	}
+}
+
+func TestIndexMigrationSample(t *testing.T) {
+	tests := []struct {
+		name       string
+		sampleSize int64
+		newHits    string
+		err        string
+	}{
+		{"same top documents", 2, `{"_id":"2"},{"_id":"1"}`, ""},
+		{"different top documents", 2, `{"_id":"1"},{"_id":"3"}`, "document 2 of products_v1 is not in the top 2"},
+		{"sample disabled", 0, `{"_id":"1"},{"_id":"3"}`, ""},
+	}
+	for _, tt := range tests {
+		cluster := newFakeCluster()
+		cluster.hits["products_v2"] = tt.newHits
+		_, err := newTestMigration(cluster).SampleSize(tt.sampleSize).Do(context.Background())
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestIndexMigrationRetireDelete(t *testing.T) {
+	cluster := newFakeCluster()
+	if _, err := newTestMigration(cluster).Retire("delete").Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Actions []map[string]map[string]interface{} `json:"actions"`
+	}
+	if err := json.Unmarshal(cluster.bodies["POST _aliases"], &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Actions) != 2 || body.Actions[0]["add"]["index"] != "products_v2" ||
+		body.Actions[1]["remove_index"]["index"] != "products_v1" {
+		t.Errorf("aliases body %s", cluster.bodies["POST _aliases"])
+	}
+	for _, request := range cluster.requests {
+		if strings.HasSuffix(request, "/_close") {
+			t.Errorf("unexpected %s when deleting the old index", request)
+		}
+	}
+}
+
+func TestIndexMigrationDryRun(t *testing.T) {
+	cluster := newFakeCluster()
+	var out bytes.Buffer
+	result, err := newTestMigration(cluster).DryRun(&out).Do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NewIndex != "products_v2" {
+		t.Fatalf("new index %s", result.NewIndex)
+	}
+	for _, request := range cluster.requests {
+		if !strings.HasPrefix(request, "GET ") {
+			t.Errorf("dry run sent %s", request)
+		}
+	}
+	printed := out.String()
+	for _, request := range []string{
+		"PUT /_index_template/products",
+		"PUT /products_v2",
+		"PUT /products_v1/_settings",
+		"POST /_reindex?wait_for_completion=false",
+		"POST /products_v2/_refresh",
+		"POST /_aliases",
+		"POST /products_v1/_close",
+	} {
+		if !strings.Contains(printed, request+"\n") {
+			t.Errorf("dry run did not print %s:\n%s", request, printed)
+		}
+	}
+}
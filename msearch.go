@@ -0,0 +1,236 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/search-multi-search.html
+type msearch struct {
+	items []*msearchItem
+}
+
+type msearchItem struct {
+	header *msearchHeader
+	dsl    *dsl
+}
+
+// msearchHeader is the header line preceding each search of an msearch body.
+type msearchHeader struct {
+	index      []string
+	routing    string
+	preference string
+	searchType string
+}
+
+// NewMsearchHeader creates and initializes a new msearchHeader.
+func NewMsearchHeader(index ...string) *msearchHeader {
+	return &msearchHeader{index: append(make([]string, 0), index...)}
+}
+
+// Routing routes the search to the shards of the given routing value.
+func (h *msearchHeader) Routing(routing string) *msearchHeader {
+	h.routing = routing
+	return h
+}
+
+// Preference selects the nodes and shards used for the search.
+func (h *msearchHeader) Preference(preference string) *msearchHeader {
+	h.preference = preference
+	return h
+}
+
+// SearchType is query_then_fetch (default) or dfs_query_then_fetch.
+func (h *msearchHeader) SearchType(searchType string) *msearchHeader {
+	h.searchType = searchType
+	return h
+}
+
+func (h *msearchHeader) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	if len(h.index) == 1 {
+		source["index"] = h.index[0]
+	} else if len(h.index) > 1 {
+		source["index"] = h.index
+	}
+	if h.routing != "" {
+		source["routing"] = h.routing
+	}
+	if h.preference != "" {
+		source["preference"] = h.preference
+	}
+	if h.searchType != "" {
+		source["search_type"] = h.searchType
+	}
+	return source, nil
+}
+
+// NewMsearch creates and initializes a new msearch.
+func NewMsearch() *msearch {
+	return &msearch{items: make([]*msearchItem, 0)}
+}
+
+// Add adds a search. header may be nil to search the default indices.
+func (m *msearch) Add(header *msearchHeader, dsl *dsl) *msearch {
+	if header == nil {
+		header = NewMsearchHeader()
+	}
+	m.items = append(m.items, &msearchItem{header: header, dsl: dsl})
+	return m
+}
+
+// BuildNdjson returns the newline delimited body of the msearch request.
+func (m *msearch) BuildNdjson() (string, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if len(m.items) == 0 {
+		return "", fmt.Errorf("msearch: at least one search must be added")
+	}
+	var sb strings.Builder
+	for i, item := range m.items {
+		header, err := item.header.Build()
+		if err != nil {
+			return "", err
+		}
+		if item.dsl == nil {
+			return "", fmt.Errorf("msearch: search %d has no body", i)
+		}
+		body, err := item.dsl.Build()
+		if err != nil {
+			return "", fmt.Errorf("msearch: search %d: %v", i, err)
+		}
+		if err := mergeMsearchParams(header.(map[string]interface{}), item.dsl.URLParams()); err != nil {
+			return "", fmt.Errorf("msearch: search %d: %v", i, err)
+		}
+		for _, line := range []interface{}{header, body} {
+			data, err := json.Marshal(line)
+			if err != nil {
+				return "", err
+			}
+			sb.Write(data)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String(), nil
+}
+
+// msearchHeaderParams are the search URL parameters an msearch header can
+// carry, with whether their value is a boolean.
+var msearchHeaderParams = map[string]bool{
+	"routing":                      false,
+	"preference":                   false,
+	"search_type":                  false,
+	"request_cache":                true,
+	"allow_partial_search_results": true,
+	"ignore_unavailable":           true,
+}
+
+// mergeMsearchParams copies the URL parameters of a dsl into its header.
+// Parameters msearch can not express per search, such as scroll, are
+// rejected, as are values conflicting with the header.
+func mergeMsearchParams(header map[string]interface{}, params url.Values) error {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		isBool, ok := msearchHeaderParams[name]
+		if !ok {
+			return fmt.Errorf("parameter %s is not supported by msearch", name)
+		}
+		var value interface{} = params.Get(name)
+		if isBool {
+			b, err := strconv.ParseBool(params.Get(name))
+			if err != nil {
+				return fmt.Errorf("invalid %s %q", name, params.Get(name))
+			}
+			value = b
+		}
+		if existing, ok := header[name]; ok && existing != value {
+			return fmt.Errorf("%s %v conflicts with header %s %v", name, value, name, existing)
+		}
+		header[name] = value
+	}
+	return nil
+}
+
+// msearchResult is the outcome of one search of an msearch request.
+// Err is set instead of Response when that search failed.
+type msearchResult struct {
+	Status   int
+	Response *searchResponse
+	Err      *ResponseError
+}
+
+// ParseMsearchResponse splits the responses array of an msearch response
+// into one result per search, in request order.
+func ParseMsearchResponse(data []byte) ([]*msearchResult, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	var resp struct {
+		Responses []jsoniter.RawMessage `json:"responses"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	results := make([]*msearchResult, 0, len(resp.Responses))
+	for _, raw := range resp.Responses {
+		var head struct {
+			Status int         `json:"status"`
+			Error  interface{} `json:"error"`
+		}
+		if err := json.Unmarshal(raw, &head); err != nil {
+			return nil, err
+		}
+		result := &msearchResult{Status: head.Status}
+		if head.Error != nil {
+			status := head.Status
+			if status == 0 {
+				status = 500
+			}
+			result.Err = newResponseError(status, raw)
+		} else {
+			sr, err := ParseSearchResponse(raw)
+			if err != nil {
+				return nil, err
+			}
+			result.Response = sr
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Do sends the msearch request. index is the default index of searches
+// whose header has none and may be empty.
+func (m *msearch) Do(ctx context.Context, t Transport, index string) ([]*msearchResult, error) {
+	body, err := m.BuildNdjson()
+	if err != nil {
+		return nil, err
+	}
+	path := "_msearch"
+	if index != "" {
+		path = url.PathEscape(index) + "/_msearch"
+	}
+	status, data, err := t.Perform(ctx, "POST", path, []byte(body))
+	if err != nil {
+		return nil, err
+	}
+	if status < 200 || status > 299 {
+		return nil, newResponseError(status, data)
+	}
+	results, err := ParseMsearchResponse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(m.items) {
+		return nil, fmt.Errorf("msearch: got %d responses for %d searches", len(results), len(m.items))
+	}
+	return results, nil
+}
@@ -0,0 +1,327 @@
+package esbuilder
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// RenderMustache renders a search template the way Elasticsearch does with
+// the JSON content type: {{var}} is JSON string escaped, {{{var}}} and
+// {{&var}} are not, and the {{#toJson}}, {{#join}} and {{#url}} functions
+// are supported. Default values are written with inverted sections, e.g.
+// {{size}}{{^size}}10{{/size}}. Partials and delimiter changes are not
+// supported.
+func RenderMustache(template string, params map[string]interface{}) (string, error) {
+	nodes, err := parseMustache(template)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := renderMustache(&sb, nodes, []interface{}{params}); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+const (
+	mustacheText = iota
+	mustacheVar
+	mustacheRaw
+	mustacheSection
+	mustacheInverted
+)
+
+type mustacheNode struct {
+	kind     int
+	value    string
+	children []*mustacheNode
+}
+
+var mustacheJoinRe = regexp.MustCompile(`^join\s+delimiter\s*=\s*'([^']*)'$`)
+
+func parseMustache(template string) ([]*mustacheNode, error) {
+	type frame struct {
+		node  *mustacheNode
+		nodes []*mustacheNode
+	}
+	var stack []frame
+	var nodes []*mustacheNode
+	rest := template
+	for len(rest) > 0 {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			nodes = append(nodes, &mustacheNode{kind: mustacheText, value: rest})
+			break
+		}
+		if start > 0 {
+			nodes = append(nodes, &mustacheNode{kind: mustacheText, value: rest[:start]})
+		}
+		rest = rest[start+2:]
+
+		closing := "}}"
+		triple := strings.HasPrefix(rest, "{")
+		if triple {
+			closing = "}}}"
+			rest = rest[1:]
+		}
+		end := strings.Index(rest, closing)
+		if end < 0 {
+			return nil, fmt.Errorf("mustache: unclosed tag")
+		}
+		tag := strings.TrimSpace(rest[:end])
+		rest = rest[end+len(closing):]
+		if triple {
+			nodes = append(nodes, &mustacheNode{kind: mustacheRaw, value: tag})
+			continue
+		}
+		if tag == "" {
+			return nil, fmt.Errorf("mustache: empty tag")
+		}
+
+		name := strings.TrimSpace(tag[1:])
+		switch tag[0] {
+		case '!':
+		case '&':
+			nodes = append(nodes, &mustacheNode{kind: mustacheRaw, value: name})
+		case '#', '^':
+			kind := mustacheSection
+			if tag[0] == '^' {
+				kind = mustacheInverted
+			}
+			node := &mustacheNode{kind: kind, value: name}
+			nodes = append(nodes, node)
+			stack = append(stack, frame{node: node, nodes: nodes})
+			nodes = nil
+		case '/':
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("mustache: unexpected closing tag %q", name)
+			}
+			top := stack[len(stack)-1]
+			if top.node.value != name {
+				return nil, fmt.Errorf("mustache: closing tag %q does not match %q", name, top.node.value)
+			}
+			top.node.children = nodes
+			nodes = top.nodes
+			stack = stack[:len(stack)-1]
+		case '>', '=':
+			return nil, fmt.Errorf("mustache: tag %q is not supported", tag)
+		default:
+			nodes = append(nodes, &mustacheNode{kind: mustacheVar, value: tag})
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("mustache: unclosed section %q", stack[len(stack)-1].node.value)
+	}
+	return nodes, nil
+}
+
+func renderMustache(sb *strings.Builder, nodes []*mustacheNode, ctx []interface{}) error {
+	for _, node := range nodes {
+		switch node.kind {
+		case mustacheText:
+			sb.WriteString(node.value)
+		case mustacheVar, mustacheRaw:
+			value := lookupMustache(ctx, node.value)
+			text, err := formatMustache(value)
+			if err != nil {
+				return err
+			}
+			if node.kind == mustacheVar {
+				text = escapeMustacheJSON(text)
+			}
+			sb.WriteString(text)
+		case mustacheInverted:
+			if !truthyMustache(lookupMustache(ctx, node.value)) {
+				if err := renderMustache(sb, node.children, ctx); err != nil {
+					return err
+				}
+			}
+		case mustacheSection:
+			if err := renderMustacheSection(sb, node, ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func renderMustacheSection(sb *strings.Builder, node *mustacheNode, ctx []interface{}) error {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if node.value == "toJson" || node.value == "join" || mustacheJoinRe.MatchString(node.value) {
+		var inner strings.Builder
+		if err := renderMustache(&inner, node.children, ctx); err != nil {
+			return err
+		}
+		value := lookupMustache(ctx, strings.TrimSpace(inner.String()))
+		if node.value == "toJson" {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			sb.Write(data)
+			return nil
+		}
+		delimiter := ","
+		if m := mustacheJoinRe.FindStringSubmatch(node.value); m != nil {
+			delimiter = m[1]
+		}
+		var items []string
+		rv := reflect.ValueOf(value)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			for i := 0; i < rv.Len(); i++ {
+				text, err := formatMustache(rv.Index(i).Interface())
+				if err != nil {
+					return err
+				}
+				items = append(items, text)
+			}
+		} else if value != nil {
+			text, err := formatMustache(value)
+			if err != nil {
+				return err
+			}
+			items = append(items, text)
+		}
+		sb.WriteString(strings.Join(items, delimiter))
+		return nil
+	}
+	if node.value == "url" {
+		var inner strings.Builder
+		if err := renderMustache(&inner, node.children, ctx); err != nil {
+			return err
+		}
+		sb.WriteString(url.QueryEscape(inner.String()))
+		return nil
+	}
+
+	value := lookupMustache(ctx, node.value)
+	if !truthyMustache(value) {
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			if err := renderMustache(sb, node.children, append(ctx, rv.Index(i).Interface())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return renderMustache(sb, node.children, append(ctx, value))
+}
+
+// lookupMustache resolves a dotted name against the context stack,
+// innermost context first. Numeric segments index into lists.
+func lookupMustache(ctx []interface{}, name string) interface{} {
+	if name == "." {
+		return ctx[len(ctx)-1]
+	}
+	parts := strings.Split(name, ".")
+	for i := len(ctx) - 1; i >= 0; i-- {
+		value, ok := lookupMustachePart(ctx[i], parts[0])
+		if !ok {
+			continue
+		}
+		for _, part := range parts[1:] {
+			value, ok = lookupMustachePart(value, part)
+			if !ok {
+				return nil
+			}
+		}
+		return value
+	}
+	return nil
+}
+
+func lookupMustachePart(value interface{}, name string) (interface{}, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		v := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		if !v.IsValid() {
+			return nil, false
+		}
+		return v.Interface(), true
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 || i >= rv.Len() {
+			return nil, false
+		}
+		return rv.Index(i).Interface(), true
+	}
+	return nil, false
+}
+
+func truthyMustache(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	}
+	return true
+}
+
+func formatMustache(value interface{}) (string, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// escapeMustacheJSON escapes text for use inside a JSON string.
+func escapeMustacheJSON(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&sb, `\u%04x`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	return sb.String()
+}
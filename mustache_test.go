@@ -0,0 +1,94 @@
+package esbuilder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMustache(t *testing.T) {
+	params := map[string]interface{}{
+		"text":   `say "hi"` + "\n",
+		"size":   10,
+		"ratio":  0.5,
+		"tags":   []interface{}{"a", "b"},
+		"empty":  []interface{}{},
+		"user":   map[string]interface{}{"name": "ann", "ids": []interface{}{1, 2}},
+		"on":     true,
+		"filter": map[string]interface{}{"term": map[string]interface{}{"tag": "a"}},
+		"query":  "a b&c",
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"escaped", `{"q":"{{text}}"}`, `{"q":"say \"hi\"\n"}`},
+		{"triple raw", `{{{text}}}`, "say \"hi\"\n"},
+		{"ampersand raw", `{{& text}}`, "say \"hi\"\n"},
+		{"number", `{"size":{{size}},"r":{{ratio}}}`, `{"size":10,"r":0.5}`},
+		{"missing", `[{{nope}}]`, `[]`},
+		{"dotted", `{{user.name}} {{user.ids.1}}`, `ann 2`},
+		{"default used", `{{from}}{{^from}}0{{/from}}`, `0`},
+		{"default skipped", `{{size}}{{^size}}20{{/size}}`, `10`},
+		{"list section", `{{#tags}}<{{.}}>{{/tags}}`, `<a><b>`},
+		{"empty list section", `{{#empty}}x{{/empty}}`, ``},
+		{"bool section", `{{#on}}yes{{/on}}{{^on}}no{{/on}}`, `yes`},
+		{"outer context", `{{#tags}}{{user.name}}{{/tags}}`, `annann`},
+		{"toJson", `{{#toJson}}filter{{/toJson}}`, `{"term":{"tag":"a"}}`},
+		{"join", `{{#join}}tags{{/join}}`, `a,b`},
+		{"join delimiter", `{{#join delimiter=' OR '}}tags{{/join delimiter=' OR '}}`, `a OR b`},
+		{"url", `{{#url}}{{query}}{{/url}}`, `a+b%26c`},
+		{"comment", `a{{! ignored }}b`, `ab`},
+	}
+	for _, tt := range tests {
+		got, err := RenderMustache(tt.template, params)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMustacheErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		err      string
+	}{
+		{`{{size`, "unclosed tag"},
+		{`{{}}`, "empty tag"},
+		{`{{/size}}`, "unexpected closing tag"},
+		{`{{#a}}{{/b}}`, "does not match"},
+		{`{{#a}}`, "unclosed section"},
+		{`{{> partial}}`, "not supported"},
+		{`{{=<% %>=}}`, "not supported"},
+	}
+	for _, tt := range tests {
+		_, err := RenderMustache(tt.template, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: err = %v, want %q", tt.template, err, tt.err)
+		}
+	}
+}
+
+func TestSearchTemplateRender(t *testing.T) {
+	tmpl := NewInlineSearchTemplate(`{"query":{"match":{"title":"{{q}}"}},"size":{{size}}{{^size}}10{{/size}}}`).Param("q", "go")
+	body, err := tmpl.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["size"] != float64(10) {
+		t.Errorf("size = %v", body["size"])
+	}
+	if _, err := NewSearchTemplate("stored").Render(); err == nil {
+		t.Error("stored template rendered locally")
+	}
+	if _, err := NewInlineSearchTemplate(`{"size":{{size}}`).Render(); err == nil {
+		t.Error("invalid JSON accepted")
+	}
+	if _, err := (&searchTemplate{}).Build(); err == nil {
+		t.Error("template without id and source accepted")
+	}
+}
@@ -0,0 +1,78 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-nested-query.html
+type nestedQuery struct {
+	path           string
+	queryItem      query
+	scoreMode      string
+	ignoreUnmapped *bool
+	innerHits      *innerHits
+	boost          *float64
+}
+
+// NewNestedQuery creates and initializes a new nestedQuery.
+func NewNestedQuery(path string, query query) *nestedQuery {
+	return &nestedQuery{path: path, queryItem: query}
+}
+
+// ScoreMode specifies how scores of matching child objects affect the
+// root document score: avg (default), max, min, none or sum.
+func (q *nestedQuery) ScoreMode(scoreMode string) *nestedQuery {
+	q.scoreMode = scoreMode
+	return q
+}
+
+// IgnoreUnmapped ignores an unmapped path instead of returning an error.
+func (q *nestedQuery) IgnoreUnmapped(ignoreUnmapped bool) *nestedQuery {
+	q.ignoreUnmapped = &ignoreUnmapped
+	return q
+}
+
+// InnerHits returns the matching nested objects with each hit.
+func (q *nestedQuery) InnerHits(innerHits *innerHits) *nestedQuery {
+	q.innerHits = innerHits
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *nestedQuery) Boost(boost float64) *nestedQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the nested query.
+func (q *nestedQuery) Build() (interface{}, error) {
+	if q.path == "" || q.queryItem == nil {
+		return nil, fmt.Errorf("nested: path and query must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["nested"] = params
+
+	params["path"] = q.path
+	src, err := q.queryItem.Build()
+	if err != nil {
+		return nil, err
+	}
+	params["query"] = src
+	if q.scoreMode != "" {
+		params["score_mode"] = q.scoreMode
+	}
+	if q.ignoreUnmapped != nil {
+		params["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.innerHits != nil {
+		src, err := q.innerHits.Build()
+		if err != nil {
+			return nil, err
+		}
+		params["inner_hits"] = src
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
@@ -0,0 +1,63 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-parent-id-query.html
+type parentIdQuery struct {
+	childType      string
+	id             string
+	ignoreUnmapped *bool
+	innerHits      *innerHits
+	boost          *float64
+}
+
+// NewParentIdQuery creates and initializes a new parentIdQuery.
+func NewParentIdQuery(childType, id string) *parentIdQuery {
+	return &parentIdQuery{childType: childType, id: id}
+}
+
+// IgnoreUnmapped ignores an unmapped type instead of returning an error.
+func (q *parentIdQuery) IgnoreUnmapped(ignoreUnmapped bool) *parentIdQuery {
+	q.ignoreUnmapped = &ignoreUnmapped
+	return q
+}
+
+// InnerHits returns the matching child documents with each hit.
+func (q *parentIdQuery) InnerHits(innerHits *innerHits) *parentIdQuery {
+	q.innerHits = innerHits
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *parentIdQuery) Boost(boost float64) *parentIdQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the parent_id query.
+func (q *parentIdQuery) Build() (interface{}, error) {
+	if q.childType == "" || q.id == "" {
+		return nil, fmt.Errorf("parent_id: type and id must be set")
+	}
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["parent_id"] = params
+
+	params["type"] = q.childType
+	params["id"] = q.id
+	if q.ignoreUnmapped != nil {
+		params["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.innerHits != nil {
+		src, err := q.innerHits.Build()
+		if err != nil {
+			return nil, err
+		}
+		params["inner_hits"] = src
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return source, nil
+}
@@ -1,5 +1,13 @@
 package esbuilder
 
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
 type pitQuery struct {
 	id        string
 	keepAlive string
@@ -19,3 +27,29 @@ func (pit *pitQuery) Build() (interface{}, error) {
 	}
 	return source, nil
 }
+
+// OpenPit opens a point in time on index kept alive for keepAlive, e.g. "5m".
+func OpenPit(ctx context.Context, t Transport, index, keepAlive string) (string, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if index == "" || keepAlive == "" {
+		return "", fmt.Errorf("pit: index and keep_alive must be set")
+	}
+	path := url.PathEscape(index) + "/_pit?keep_alive=" + url.QueryEscape(keepAlive)
+	data, err := perform(ctx, t, "POST", path, nil)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", err
+	}
+	return resp.Id, nil
+}
+
+// ClosePit releases the point in time id.
+func ClosePit(ctx context.Context, t Transport, id string) error {
+	_, err := perform(ctx, t, "DELETE", "_pit", map[string]interface{}{"id": id})
+	return err
+}
@@ -0,0 +1,16 @@
+package esbuilder
+
+// rawQuery is a query given as an already built source, e.g. a map
+// decoded from JSON.
+type rawQuery struct {
+	source interface{}
+}
+
+// NewRawQuery creates a query returning source as is.
+func NewRawQuery(source interface{}) *rawQuery {
+	return &rawQuery{source: source}
+}
+
+func (q *rawQuery) Build() (interface{}, error) {
+	return q.source, nil
+}
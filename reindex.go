@@ -0,0 +1,303 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-reindex.html
+type reindex struct {
+	sourceIndex  []string
+	sourceQuery  query
+	sourceSize   *int
+	sourceFields []string
+	remote       *reindexRemote
+	destIndex    string
+	opType       string
+	destPipeline string
+	versionType  string
+	script       *script
+	conflicts    string
+	maxDocs      *int64
+	params       url.Values
+}
+
+// NewReindex creates and initializes a new reindex request copying
+// documents of sourceIndex.
+func NewReindex(sourceIndex ...string) *reindex {
+	return &reindex{
+		sourceIndex:  append(make([]string, 0), sourceIndex...),
+		sourceFields: make([]string, 0),
+		params:       make(url.Values),
+	}
+}
+
+// SourceQuery selects the documents to copy.
+func (r *reindex) SourceQuery(query query) *reindex {
+	r.sourceQuery = query
+	return r
+}
+
+// SourceSize is the number of documents copied per batch, 1000 by default.
+func (r *reindex) SourceSize(size int) *reindex {
+	r.sourceSize = &size
+	return r
+}
+
+// SourceFields copies only the listed fields.
+func (r *reindex) SourceFields(fields ...string) *reindex {
+	r.sourceFields = append(r.sourceFields, fields...)
+	return r
+}
+
+// Remote reads the source index from a remote cluster.
+func (r *reindex) Remote(remote *reindexRemote) *reindex {
+	r.remote = remote
+	return r
+}
+
+// Dest sets the index documents are written to.
+func (r *reindex) Dest(index string) *reindex {
+	r.destIndex = index
+	return r
+}
+
+// OpType is index (default) or create, which only copies missing documents.
+func (r *reindex) OpType(opType string) *reindex {
+	r.opType = opType
+	return r
+}
+
+// DestPipeline sets the ingest pipeline documents are sent through.
+func (r *reindex) DestPipeline(pipeline string) *reindex {
+	r.destPipeline = pipeline
+	return r
+}
+
+// VersionType is internal (default), external, external_gt or external_gte.
+func (r *reindex) VersionType(versionType string) *reindex {
+	r.versionType = versionType
+	return r
+}
+
+// Script sets the script modifying each document.
+func (r *reindex) Script(script *script) *reindex {
+	r.script = script
+	return r
+}
+
+// Conflicts is abort (default) or proceed.
+func (r *reindex) Conflicts(conflicts string) *reindex {
+	r.conflicts = conflicts
+	return r
+}
+
+// MaxDocs is the maximum number of documents to copy.
+func (r *reindex) MaxDocs(maxDocs int64) *reindex {
+	r.maxDocs = &maxDocs
+	return r
+}
+
+// Slices is the number of slices the task is divided into, 0 for auto.
+func (r *reindex) Slices(slices int) *reindex {
+	r.params.Set("slices", formatSlices(slices))
+	return r
+}
+
+// RequestsPerSecond throttles the request, -1 disables throttling.
+func (r *reindex) RequestsPerSecond(rps float64) *reindex {
+	r.params.Set("requests_per_second", strconv.FormatFloat(rps, 'f', -1, 64))
+	return r
+}
+
+// Refresh refreshes the destination index once the request completes.
+func (r *reindex) Refresh(refresh bool) *reindex {
+	r.params.Set("refresh", strconv.FormatBool(refresh))
+	return r
+}
+
+// WaitForCompletion returns a task id instead of waiting when set to false.
+func (r *reindex) WaitForCompletion(wait bool) *reindex {
+	r.params.Set("wait_for_completion", strconv.FormatBool(wait))
+	return r
+}
+
+// Build returns the body of the reindex request.
+func (r *reindex) Build() (interface{}, error) {
+	if len(r.sourceIndex) == 0 || r.destIndex == "" {
+		return nil, fmt.Errorf("reindex: source and dest index must be set")
+	}
+	if r.remote == nil && containsString(r.sourceIndex, r.destIndex) {
+		return nil, fmt.Errorf("reindex: can not write into index %s while reading from it", r.destIndex)
+	}
+	if r.remote != nil && r.params.Get("slices") != "" {
+		return nil, fmt.Errorf("reindex: slices are not supported when reindexing from remote")
+	}
+	if r.opType != "" && r.opType != "index" && r.opType != "create" {
+		return nil, fmt.Errorf("reindex: op_type must be index or create")
+	}
+	switch r.versionType {
+	case "", "internal", "external", "external_gt", "external_gte":
+	default:
+		return nil, fmt.Errorf("reindex: invalid version_type %q", r.versionType)
+	}
+	if r.conflicts != "" && r.conflicts != "abort" && r.conflicts != "proceed" {
+		return nil, fmt.Errorf("reindex: conflicts must be abort or proceed")
+	}
+
+	src := make(map[string]interface{})
+	if len(r.sourceIndex) == 1 {
+		src["index"] = r.sourceIndex[0]
+	} else {
+		src["index"] = r.sourceIndex
+	}
+	if r.sourceQuery != nil {
+		q, err := r.sourceQuery.Build()
+		if err != nil {
+			return nil, err
+		}
+		src["query"] = q
+	}
+	if r.sourceSize != nil {
+		src["size"] = *r.sourceSize
+	}
+	if len(r.sourceFields) > 0 {
+		src["_source"] = r.sourceFields
+	}
+	if r.remote != nil {
+		remote, err := r.remote.Build()
+		if err != nil {
+			return nil, err
+		}
+		src["remote"] = remote
+	}
+	dest := map[string]interface{}{
+		"index": r.destIndex,
+	}
+	if r.opType != "" {
+		dest["op_type"] = r.opType
+	}
+	if r.destPipeline != "" {
+		dest["pipeline"] = r.destPipeline
+	}
+	if r.versionType != "" {
+		dest["version_type"] = r.versionType
+	}
+	source := map[string]interface{}{
+		"source": src,
+		"dest":   dest,
+	}
+	if r.script != nil {
+		s, err := r.script.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["script"] = s
+	}
+	if r.conflicts != "" {
+		source["conflicts"] = r.conflicts
+	}
+	if r.maxDocs != nil {
+		source["max_docs"] = *r.maxDocs
+	}
+	return source, nil
+}
+
+// Path returns the path and URL parameters of the request.
+func (r *reindex) Path() string {
+	path := "_reindex"
+	if len(r.params) > 0 {
+		path += "?" + r.params.Encode()
+	}
+	return path
+}
+
+// Do sends the reindex request. With WaitForCompletion(false) only the
+// Task of the response is set, see WaitForTask.
+func (r *reindex) Do(ctx context.Context, t Transport) (*byQueryResponse, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	src, err := r.Build()
+	if err != nil {
+		return nil, err
+	}
+	data, err := perform(ctx, t, "POST", r.Path(), src)
+	if err != nil {
+		return nil, err
+	}
+	resp := &byQueryResponse{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// reindexRemote is the remote cluster a reindex reads from.
+type reindexRemote struct {
+	host           string
+	username       string
+	password       string
+	headers        map[string]string
+	socketTimeout  string
+	connectTimeout string
+}
+
+// NewReindexRemote creates a remote source at host, e.g.
+// "https://otherhost:9200". The host must be allowed by the
+// reindex.remote.whitelist setting of the destination cluster.
+func NewReindexRemote(host string) *reindexRemote {
+	return &reindexRemote{host: host, headers: make(map[string]string)}
+}
+
+// BasicAuth sets the credentials of the remote cluster.
+func (r *reindexRemote) BasicAuth(username, password string) *reindexRemote {
+	r.username = username
+	r.password = password
+	return r
+}
+
+// Header adds a header sent to the remote cluster.
+func (r *reindexRemote) Header(name, value string) *reindexRemote {
+	r.headers[name] = value
+	return r
+}
+
+// SocketTimeout sets the read timeout, 30s by default.
+func (r *reindexRemote) SocketTimeout(timeout string) *reindexRemote {
+	r.socketTimeout = timeout
+	return r
+}
+
+// ConnectTimeout sets the connection timeout, 30s by default.
+func (r *reindexRemote) ConnectTimeout(timeout string) *reindexRemote {
+	r.connectTimeout = timeout
+	return r
+}
+
+func (r *reindexRemote) Build() (interface{}, error) {
+	if !strings.HasPrefix(r.host, "http://") && !strings.HasPrefix(r.host, "https://") {
+		return nil, fmt.Errorf("reindex remote: host must start with http:// or https://")
+	}
+	source := map[string]interface{}{
+		"host": r.host,
+	}
+	if r.username != "" {
+		source["username"] = r.username
+		source["password"] = r.password
+	}
+	if len(r.headers) > 0 {
+		source["headers"] = r.headers
+	}
+	if r.socketTimeout != "" {
+		source["socket_timeout"] = r.socketTimeout
+	}
+	if r.connectTimeout != "" {
+		source["connect_timeout"] = r.connectTimeout
+	}
+	return source, nil
+}
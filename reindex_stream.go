@@ -0,0 +1,276 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+)
+
+// reindexCheckpoint is the progress of a streamingReindex. It is
+// JSON-serializable so it can be persisted and passed to Resume.
+type reindexCheckpoint struct {
+	PitId       string        `json:"pit_id,omitempty"`
+	SearchAfter []interface{} `json:"search_after,omitempty"`
+	Processed   int64         `json:"processed"`
+	Indexed     int64         `json:"indexed"`
+	Skipped     int64         `json:"skipped"`
+	Conflicts   int64         `json:"conflicts"`
+	Done        bool          `json:"done"`
+}
+
+// streamingReindex copies documents through the client: it reads the
+// source with a point in time and search_after, transforms each hit in Go
+// and writes the results with bulk requests. A checkpoint is reported
+// after each batch is written.
+type streamingReindex struct {
+	t           Transport
+	sourceIndex string
+	destIndex   string
+	queryItem   query
+	sorts       []query
+	batchSize   int64
+	keepAlive   string
+	maxDocs     int64
+	conflicts   string
+	transform   func(hit *searchHit) (*bulkAction, error)
+	checkpoint  func(cp *reindexCheckpoint) error
+	resume      *reindexCheckpoint
+}
+
+// NewStreamingReindex creates a client side reindex from sourceIndex to
+// destIndex.
+func NewStreamingReindex(t Transport, sourceIndex, destIndex string) *streamingReindex {
+	return &streamingReindex{
+		t:           t,
+		sourceIndex: sourceIndex,
+		destIndex:   destIndex,
+		sorts:       make([]query, 0),
+		batchSize:   1000,
+		keepAlive:   "5m",
+	}
+}
+
+// Query selects the documents to copy.
+func (r *streamingReindex) Query(query query) *streamingReindex {
+	r.queryItem = query
+	return r
+}
+
+// Sort sets a total order of the source documents, e.g. on a unique
+// keyword field, and is required. _doc alone is not a total order across
+// shards, so search_after would skip documents.
+func (r *streamingReindex) Sort(sorts ...query) *streamingReindex {
+	r.sorts = append(r.sorts, sorts...)
+	return r
+}
+
+// BatchSize is the number of documents read and written per batch.
+func (r *streamingReindex) BatchSize(size int64) *streamingReindex {
+	r.batchSize = size
+	return r
+}
+
+// KeepAlive sets how long the point in time is kept between batches.
+func (r *streamingReindex) KeepAlive(keepAlive string) *streamingReindex {
+	r.keepAlive = keepAlive
+	return r
+}
+
+// MaxDocs stops after maxDocs source documents.
+func (r *streamingReindex) MaxDocs(maxDocs int64) *streamingReindex {
+	r.maxDocs = maxDocs
+	return r
+}
+
+// Conflicts is abort (default) or proceed, which counts version conflicts
+// instead of failing.
+func (r *streamingReindex) Conflicts(conflicts string) *streamingReindex {
+	r.conflicts = conflicts
+	return r
+}
+
+// Transform converts each source hit to the bulk action writing it. A nil
+// action skips the hit. Actions without index are written to the
+// destination index. By default hits are indexed unchanged with their
+// _id and routing.
+func (r *streamingReindex) Transform(fn func(hit *searchHit) (*bulkAction, error)) *streamingReindex {
+	r.transform = fn
+	return r
+}
+
+// Checkpoint is called with the progress after each written batch. An
+// error stops the reindex.
+func (r *streamingReindex) Checkpoint(fn func(cp *reindexCheckpoint) error) *streamingReindex {
+	r.checkpoint = fn
+	return r
+}
+
+// Resume continues from a checkpoint reported by a previous run.
+func (r *streamingReindex) Resume(cp *reindexCheckpoint) *streamingReindex {
+	r.resume = cp
+	return r
+}
+
+// Do runs the reindex until all documents are copied and returns the
+// final checkpoint. On error the last checkpoint is returned along with
+// it and the point in time is left open so the run can be resumed.
+func (r *streamingReindex) Do(ctx context.Context) (*reindexCheckpoint, error) {
+	if r.sourceIndex == "" || r.destIndex == "" {
+		return nil, fmt.Errorf("streaming reindex: source and dest index must be set")
+	}
+	if r.sourceIndex == r.destIndex {
+		return nil, fmt.Errorf("streaming reindex: can not write into index %s while reading from it", r.destIndex)
+	}
+	if len(r.sorts) == 0 {
+		return nil, fmt.Errorf("streaming reindex: sort must be set to a total order of the source documents")
+	}
+	if r.batchSize <= 0 {
+		return nil, fmt.Errorf("streaming reindex: batch size must be positive")
+	}
+	if r.conflicts != "" && r.conflicts != "abort" && r.conflicts != "proceed" {
+		return nil, fmt.Errorf("streaming reindex: conflicts must be abort or proceed")
+	}
+	cp := &reindexCheckpoint{}
+	if r.resume != nil {
+		copied := *r.resume
+		cp = &copied
+		if cp.Done {
+			return cp, nil
+		}
+	}
+	if cp.PitId == "" {
+		id, err := OpenPit(ctx, r.t, r.sourceIndex, r.keepAlive)
+		if err != nil {
+			return cp, err
+		}
+		cp.PitId = id
+	}
+
+	// pitId is the point in time in use, which differs from the one of the
+	// last reported checkpoint once the search reopened or renewed it.
+	pitId := cp.PitId
+	for r.maxDocs <= 0 || cp.Processed < r.maxDocs {
+		resp, id, err := r.search(ctx, cp, pitId)
+		if err != nil {
+			return cp, err
+		}
+		pitId = id
+		hits := resp.Hits.Hits
+		if r.maxDocs > 0 && int64(len(hits)) > r.maxDocs-cp.Processed {
+			hits = hits[:r.maxDocs-cp.Processed]
+		}
+		if len(hits) == 0 {
+			break
+		}
+		next := *cp
+		if err := r.write(ctx, hits, &next); err != nil {
+			return cp, err
+		}
+		next.SearchAfter = hits[len(hits)-1].Sort
+		next.PitId = pitId
+		cp = &next
+		if r.checkpoint != nil {
+			if err := r.checkpoint(cp); err != nil {
+				return cp, err
+			}
+		}
+		if int64(len(resp.Hits.Hits)) < r.batchSize {
+			break
+		}
+	}
+
+	// A point in time that expired in the meantime is already released.
+	if err := ClosePit(ctx, r.t, pitId); err != nil {
+		if re, ok := err.(*ResponseError); !ok || re.Status != 404 {
+			return cp, err
+		}
+	}
+	done := *cp
+	done.PitId = ""
+	done.Done = true
+	if r.checkpoint != nil {
+		if err := r.checkpoint(&done); err != nil {
+			return &done, err
+		}
+	}
+	return &done, nil
+}
+
+// search reads the batch after cp using the point in time pitId. It
+// returns the id of the point in time to continue with, which is a new
+// one when pitId expired.
+func (r *streamingReindex) search(ctx context.Context, cp *reindexCheckpoint, pitId string) (*searchResponse, string, error) {
+	dsl := NewDsl()
+	if r.queryItem != nil {
+		dsl.SetQuery(r.queryItem)
+	}
+	dsl.SetSize(r.batchSize)
+	dsl.OrderItems = append(dsl.OrderItems, r.sorts...)
+	if len(cp.SearchAfter) > 0 {
+		dsl.SetSearchAfter(cp.SearchAfter)
+	}
+	dsl.SetPit(NewPitQuery(pitId, r.keepAlive))
+	resp, err := Search(ctx, r.t, "", dsl)
+	if re, ok := err.(*ResponseError); ok && re.Status == 404 {
+		if pitId, err = OpenPit(ctx, r.t, r.sourceIndex, r.keepAlive); err != nil {
+			return nil, "", err
+		}
+		dsl.SetPit(NewPitQuery(pitId, r.keepAlive))
+		if resp, err = Search(ctx, r.t, "", dsl); err != nil {
+			return nil, "", err
+		}
+	} else if err != nil {
+		return nil, "", err
+	}
+	if resp.Hits == nil {
+		return nil, "", fmt.Errorf("streaming reindex: search response without hits")
+	}
+	if resp.PitId != "" {
+		pitId = resp.PitId
+	}
+	return resp, pitId, nil
+}
+
+// write transforms hits and bulk writes them, updating the counters of cp.
+func (r *streamingReindex) write(ctx context.Context, hits []*searchHit, cp *reindexCheckpoint) error {
+	b := NewBulk()
+	for _, hit := range hits {
+		cp.Processed++
+		var action *bulkAction
+		if r.transform != nil {
+			var err error
+			if action, err = r.transform(hit); err != nil {
+				return fmt.Errorf("streaming reindex: transform %s: %v", hit.Id, err)
+			}
+		} else {
+			action = NewBulkIndexAction(hit.Source).Id(hit.Id).Routing(hit.Routing)
+		}
+		if action == nil {
+			cp.Skipped++
+			continue
+		}
+		b.Add(action)
+	}
+	if len(b.actions) == 0 {
+		return nil
+	}
+	resp, err := b.Do(ctx, r.t, r.destIndex)
+	if err != nil {
+		return err
+	}
+	for i := range b.actions {
+		item := resp.item(i)
+		if item == nil {
+			return fmt.Errorf("streaming reindex: missing response item %d", i)
+		}
+		err := item.Err()
+		switch {
+		case err == nil:
+			cp.Indexed++
+		case item.Status == 409 && r.conflicts == "proceed":
+			cp.Conflicts++
+		default:
+			return fmt.Errorf("streaming reindex: document %s: %v", item.Id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package esbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeReindexCluster serves the documents "0" to "<docs-1>" of the source
+// index through points in time and answers bulk requests to the
+// destination with the status returned by status for each _id.
+type fakeReindexCluster struct {
+	docs    int
+	pits    int
+	expire  bool
+	status  func(id string) int
+	written []string
+}
+
+func (c *fakeReindexCluster) Perform(ctx context.Context, method, path string, body []byte) (int, []byte, error) {
+	switch {
+	case strings.HasSuffix(strings.SplitN(path, "?", 2)[0], "/_pit"):
+		c.pits++
+		return 200, []byte(fmt.Sprintf(`{"id":"pit%d"}`, c.pits)), nil
+	case path == "_pit":
+		return 200, []byte(`{"succeeded":true}`), nil
+	case strings.HasPrefix(path, "_search"):
+		var req struct {
+			Size        int           `json:"size"`
+			SearchAfter []interface{} `json:"search_after"`
+			Pit         struct {
+				Id string `json:"id"`
+			} `json:"pit"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return 400, nil, err
+		}
+		if c.expire && req.SearchAfter != nil {
+			c.expire = false
+			return 404, []byte(`{"error":{"type":"search_context_missing_exception","reason":"expired"}}`), nil
+		}
+		start := 0
+		if len(req.SearchAfter) > 0 {
+			fmt.Sscan(req.SearchAfter[0].(string), &start)
+			start++
+		}
+		hits := make([]string, 0)
+		for i := start; i < c.docs && len(hits) < req.Size; i++ {
+			hits = append(hits, fmt.Sprintf(`{"_index":"src","_id":"%d","_source":{"n":%d},"sort":["%d"]}`, i, i, i))
+		}
+		return 200, []byte(`{"pit_id":"` + req.Pit.Id + `","hits":{"hits":[` + strings.Join(hits, ",") + `]}}`), nil
+	case strings.HasSuffix(path, "/_bulk"):
+		items := make([]string, 0)
+		for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+			var action map[string]map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &action); err != nil {
+				continue
+			}
+			meta, ok := action["index"]
+			if !ok || len(action) != 1 {
+				continue
+			}
+			id, _ := meta["_id"].(string)
+			status := 201
+			if c.status != nil {
+				status = c.status(id)
+			}
+			if status < 0 {
+				continue
+			}
+			c.written = append(c.written, id)
+			items = append(items, fmt.Sprintf(`{"index":{"_index":"dst","_id":%q,"status":%d}}`, id, status))
+		}
+		return 200, []byte(`{"items":[` + strings.Join(items, ",") + `]}`), nil
+	}
+	return 400, []byte(`{"error":{"type":"illegal_argument_exception","reason":"unexpected request"}}`), nil
+}
+
+func TestStreamingReindex(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster *fakeReindexCluster
+		setup   func(r *streamingReindex)
+		want    reindexCheckpoint
+		pits    int
+		batches int
+		err     string
+	}{
+		{
+			name:    "copy all",
+			cluster: &fakeReindexCluster{docs: 5},
+			want:    reindexCheckpoint{Processed: 5, Indexed: 5, Done: true},
+			pits:    1,
+			batches: 3,
+		},
+		{
+			name:    "max docs",
+			cluster: &fakeReindexCluster{docs: 5},
+			setup:   func(r *streamingReindex) { r.MaxDocs(3) },
+			want:    reindexCheckpoint{Processed: 3, Indexed: 3, Done: true},
+			pits:    1,
+			batches: 2,
+		},
+		{
+			name:    "skip in transform",
+			cluster: &fakeReindexCluster{docs: 4},
+			setup: func(r *streamingReindex) {
+				r.Transform(func(hit *searchHit) (*bulkAction, error) {
+					if hit.Id == "1" {
+						return nil, nil
+					}
+					return NewBulkIndexAction(hit.Source).Id(hit.Id), nil
+				})
+			},
+			want:    reindexCheckpoint{Processed: 4, Indexed: 3, Skipped: 1, Done: true},
+			pits:    1,
+			batches: 2,
+		},
+		{
+			name: "conflicts proceed",
+			cluster: &fakeReindexCluster{docs: 3, status: func(id string) int {
+				if id == "2" {
+					return 409
+				}
+				return 201
+			}},
+			setup:   func(r *streamingReindex) { r.Conflicts("proceed") },
+			want:    reindexCheckpoint{Processed: 3, Indexed: 2, Conflicts: 1, Done: true},
+			pits:    1,
+			batches: 2,
+		},
+		{
+			name:    "expired point in time",
+			cluster: &fakeReindexCluster{docs: 5, expire: true},
+			want:    reindexCheckpoint{Processed: 5, Indexed: 5, Done: true},
+			pits:    2,
+			batches: 3,
+		},
+		{
+			name:    "conflicts abort",
+			cluster: &fakeReindexCluster{docs: 3, status: func(id string) int { return 409 }},
+			want:    reindexCheckpoint{PitId: "pit1"},
+			pits:    1,
+			err:     "document 0",
+		},
+		{
+			name: "missing response item",
+			cluster: &fakeReindexCluster{docs: 2, status: func(id string) int {
+				if id == "1" {
+					return -1
+				}
+				return 201
+			}},
+			want: reindexCheckpoint{PitId: "pit1"},
+			pits: 1,
+			err:  "missing response item 1",
+		},
+		{
+			name:    "no sort",
+			cluster: &fakeReindexCluster{docs: 2},
+			setup:   func(r *streamingReindex) { r.sorts = r.sorts[:0] },
+			err:     "sort must be set",
+		},
+	}
+	for _, tt := range tests {
+		var checkpoints []reindexCheckpoint
+		r := NewStreamingReindex(tt.cluster, "src", "dst").
+			Sort(NewSortQuery("id", "asc")).
+			BatchSize(2).
+			Checkpoint(func(cp *reindexCheckpoint) error {
+				checkpoints = append(checkpoints, *cp)
+				return nil
+			})
+		if tt.setup != nil {
+			tt.setup(r)
+		}
+		cp, err := r.Do(context.Background())
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if tt.want.PitId == "" && tt.err != "" {
+			if cp != nil {
+				t.Errorf("%s: checkpoint %+v", tt.name, cp)
+			}
+			continue
+		}
+		got := *cp
+		got.SearchAfter = nil
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: checkpoint %+v, want %+v", tt.name, got, tt.want)
+		}
+		if tt.cluster.pits != tt.pits {
+			t.Errorf("%s: %d points in time opened, want %d", tt.name, tt.cluster.pits, tt.pits)
+		}
+		if tt.err == "" && len(checkpoints) != tt.batches+1 {
+			t.Errorf("%s: %d checkpoints, want %d batches and the final one", tt.name, len(checkpoints), tt.batches)
+		}
+	}
+}
+
+func TestStreamingReindexResume(t *testing.T) {
+	cluster := &fakeReindexCluster{docs: 5}
+	cp := &reindexCheckpoint{PitId: "pit0", SearchAfter: []interface{}{"2"}, Processed: 3, Indexed: 3}
+	done, err := NewStreamingReindex(cluster, "src", "dst").
+		Sort(NewSortQuery("id", "asc")).
+		BatchSize(2).
+		Resume(cp).
+		Do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Processed != 5 || done.Indexed != 5 || !done.Done {
+		t.Errorf("checkpoint %+v", done)
+	}
+	if strings.Join(cluster.written, ",") != "3,4" {
+		t.Errorf("written %v, want 3,4", cluster.written)
+	}
+	if cluster.pits != 0 {
+		t.Errorf("%d points in time opened when resuming", cluster.pits)
+	}
+	if cp.Processed != 3 {
+		t.Error("Resume modified the checkpoint")
+	}
+}
@@ -0,0 +1,77 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/filter-search-results.html#rescore
+type queryRescorer struct {
+	windowSize         *int
+	rescoreQuery       query
+	queryWeight        *float64
+	rescoreQueryWeight *float64
+	scoreMode          string
+}
+
+// NewQueryRescorer creates and initializes a new queryRescorer running
+// rescoreQuery on the top hits.
+func NewQueryRescorer(rescoreQuery query) *queryRescorer {
+	return &queryRescorer{rescoreQuery: rescoreQuery}
+}
+
+// WindowSize is the number of top hits examined on each shard. Defaults to 10.
+func (r *queryRescorer) WindowSize(windowSize int) *queryRescorer {
+	r.windowSize = &windowSize
+	return r
+}
+
+// QueryWeight is the weight of the original query score. Defaults to 1.
+func (r *queryRescorer) QueryWeight(queryWeight float64) *queryRescorer {
+	r.queryWeight = &queryWeight
+	return r
+}
+
+// RescoreQueryWeight is the weight of the rescore query score. Defaults to 1.
+func (r *queryRescorer) RescoreQueryWeight(rescoreQueryWeight float64) *queryRescorer {
+	r.rescoreQueryWeight = &rescoreQueryWeight
+	return r
+}
+
+// ScoreMode combines the scores: total (default), multiply, avg, max or min.
+func (r *queryRescorer) ScoreMode(scoreMode string) *queryRescorer {
+	r.scoreMode = scoreMode
+	return r
+}
+
+// Build returns the map for one rescore entry.
+func (r *queryRescorer) Build() (interface{}, error) {
+	if r.rescoreQuery == nil {
+		return nil, fmt.Errorf("rescore: rescore_query must be set")
+	}
+	params := make(map[string]interface{})
+	src, err := r.rescoreQuery.Build()
+	if err != nil {
+		return nil, err
+	}
+	params["rescore_query"] = src
+	if r.queryWeight != nil {
+		params["query_weight"] = *r.queryWeight
+	}
+	if r.rescoreQueryWeight != nil {
+		params["rescore_query_weight"] = *r.rescoreQueryWeight
+	}
+	if r.scoreMode != "" {
+		switch r.scoreMode {
+		case "total", "multiply", "avg", "max", "min":
+		default:
+			return nil, fmt.Errorf("rescore: invalid score_mode %q", r.scoreMode)
+		}
+		params["score_mode"] = r.scoreMode
+	}
+	source := map[string]interface{}{
+		"query": params,
+	}
+	if r.windowSize != nil {
+		source["window_size"] = *r.windowSize
+	}
+	return source, nil
+}
@@ -0,0 +1,131 @@
+package esbuilder
+
+import jsoniter "github.com/json-iterator/go"
+
+// searchResponse is the decoded body of a search request.
+type searchResponse struct {
+	Took         int64                          `json:"took"`
+	TimedOut     bool                           `json:"timed_out"`
+	Shards       *shardsInfo                    `json:"_shards,omitempty"`
+	Hits         *searchHits                    `json:"hits,omitempty"`
+	Aggregations map[string]jsoniter.RawMessage `json:"aggregations,omitempty"`
+	Suggest      map[string][]*suggestion       `json:"suggest,omitempty"`
+	PitId        string                         `json:"pit_id,omitempty"`
+	ScrollId     string                         `json:"_scroll_id,omitempty"`
+}
+
+type shardsInfo struct {
+	Total      int `json:"total"`
+	Successful int `json:"successful"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+}
+
+type searchHits struct {
+	Total    *totalHits   `json:"total,omitempty"`
+	MaxScore *float64     `json:"max_score,omitempty"`
+	Hits     []*searchHit `json:"hits"`
+}
+
+type totalHits struct {
+	Value    int64  `json:"value"`
+	Relation string `json:"relation"`
+}
+
+type searchHit struct {
+	Index     string                      `json:"_index"`
+	Id        string                      `json:"_id"`
+	Score     *float64                    `json:"_score,omitempty"`
+	Routing   string                      `json:"_routing,omitempty"`
+	Source    jsoniter.RawMessage         `json:"_source,omitempty"`
+	Fields    map[string]interface{}      `json:"fields,omitempty"`
+	Sort      []interface{}               `json:"sort,omitempty"`
+	Highlight map[string][]string         `json:"highlight,omitempty"`
+	InnerHits map[string]*innerHitsResult `json:"inner_hits,omitempty"`
+}
+
+type innerHitsResult struct {
+	Hits *searchHits `json:"hits"`
+}
+
+// collapsedGroup is one group of a collapsed search.
+type collapsedGroup struct {
+	Value     interface{}
+	Hit       *searchHit
+	InnerHits map[string][]*searchHit
+}
+
+type suggestion struct {
+	Text    string              `json:"text"`
+	Offset  int                 `json:"offset"`
+	Length  int                 `json:"length"`
+	Options []*suggestionOption `json:"options"`
+}
+
+type suggestionOption struct {
+	Text         string                   `json:"text"`
+	Score        float64                  `json:"score"`
+	Freq         int64                    `json:"freq,omitempty"`
+	Highlighted  string                   `json:"highlighted,omitempty"`
+	CollateMatch *bool                    `json:"collate_match,omitempty"`
+	Index        string                   `json:"_index,omitempty"`
+	Id           string                   `json:"_id,omitempty"`
+	Source       jsoniter.RawMessage      `json:"_source,omitempty"`
+	Contexts     map[string][]interface{} `json:"contexts,omitempty"`
+}
+
+// ParseSearchResponse decodes the body of a search response.
+func ParseSearchResponse(data []byte) (*searchResponse, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	resp := &searchResponse{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// TotalHits returns the number of matching documents, 0 if unknown.
+func (r *searchResponse) TotalHits() int64 {
+	if r.Hits == nil || r.Hits.Total == nil {
+		return 0
+	}
+	return r.Hits.Total.Value
+}
+
+// HighlightFragments returns the highlighted fragments of field for each
+// hit, aligned with Hits.Hits. Hits without highlights for field are nil.
+func (r *searchResponse) HighlightFragments(field string) [][]string {
+	if r.Hits == nil {
+		return make([][]string, 0)
+	}
+	fragments := make([][]string, len(r.Hits.Hits))
+	for i, hit := range r.Hits.Hits {
+		fragments[i] = hit.Highlight[field]
+	}
+	return fragments
+}
+
+// CollapsedGroups returns one group per hit of a search collapsed on field,
+// with the expanded inner_hits of each group keyed by name.
+func (r *searchResponse) CollapsedGroups(field string) []*collapsedGroup {
+	groups := make([]*collapsedGroup, 0)
+	if r.Hits == nil {
+		return groups
+	}
+	for _, hit := range r.Hits.Hits {
+		group := &collapsedGroup{
+			Hit:       hit,
+			InnerHits: make(map[string][]*searchHit),
+		}
+		if values, ok := hit.Fields[field].([]interface{}); ok && len(values) > 0 {
+			group.Value = values[0]
+		}
+		for name, inner := range hit.InnerHits {
+			if inner != nil && inner.Hits != nil {
+				group.InnerHits[name] = inner.Hits.Hits
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
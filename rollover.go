@@ -0,0 +1,252 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// rolloverConditions are the conditions shared by the rollover request and
+// the ILM rollover action.
+type rolloverConditions struct {
+	maxAge              string
+	maxDocs             *int64
+	maxSize             string
+	maxPrimaryShardSize string
+}
+
+func (c *rolloverConditions) build(source map[string]interface{}) error {
+	if c.maxAge != "" {
+		if _, err := parseTimeValue(c.maxAge); err != nil {
+			return fmt.Errorf("max_age: %v", err)
+		}
+		source["max_age"] = c.maxAge
+	}
+	if c.maxDocs != nil {
+		if *c.maxDocs < 1 {
+			return fmt.Errorf("max_docs must be positive")
+		}
+		source["max_docs"] = *c.maxDocs
+	}
+	if c.maxSize != "" {
+		if _, err := parseByteSize(c.maxSize); err != nil {
+			return fmt.Errorf("max_size: %v", err)
+		}
+		source["max_size"] = c.maxSize
+	}
+	if c.maxPrimaryShardSize != "" {
+		if _, err := parseByteSize(c.maxPrimaryShardSize); err != nil {
+			return fmt.Errorf("max_primary_shard_size: %v", err)
+		}
+		source["max_primary_shard_size"] = c.maxPrimaryShardSize
+	}
+	return nil
+}
+
+// timeUnits maps Elasticsearch time units to nanoseconds.
+var timeUnits = []struct {
+	suffix string
+	nanos  int64
+}{
+	{"nanos", 1},
+	{"micros", 1000},
+	{"ms", 1000000},
+	{"s", 1000000000},
+	{"m", 60 * 1000000000},
+	{"h", 3600 * 1000000000},
+	{"d", 86400 * 1000000000},
+}
+
+// parseTimeValue parses a time value such as "30d" or "12h" into nanoseconds.
+func parseTimeValue(value string) (int64, error) {
+	if value == "0" {
+		return 0, nil
+	}
+	for i := len(timeUnits) - 1; i >= 0; i-- {
+		unit := timeUnits[i]
+		if !strings.HasSuffix(value, unit.suffix) {
+			continue
+		}
+		number := strings.TrimSuffix(value, unit.suffix)
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			// "ms" and "nanos" also end with "s", try the next unit.
+			continue
+		}
+		if n < 0 {
+			return 0, fmt.Errorf("invalid time value %q", value)
+		}
+		return n * unit.nanos, nil
+	}
+	return 0, fmt.Errorf("invalid time value %q", value)
+}
+
+// byteUnits maps Elasticsearch byte size units to bytes.
+var byteUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"pb", 1 << 50},
+	{"tb", 1 << 40},
+	{"gb", 1 << 30},
+	{"mb", 1 << 20},
+	{"kb", 1 << 10},
+	{"b", 1},
+}
+
+// parseByteSize parses a byte size such as "50gb" into bytes.
+func parseByteSize(value string) (float64, error) {
+	lower := strings.ToLower(value)
+	for _, unit := range byteUnits {
+		if !strings.HasSuffix(lower, unit.suffix) {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSuffix(lower, unit.suffix), 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid byte size %q", value)
+		}
+		return n * unit.bytes, nil
+	}
+	return 0, fmt.Errorf("invalid byte size %q", value)
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/indices-rollover-index.html
+type rollover struct {
+	rolloverConditions
+	alias    string
+	newIndex string
+	dryRun   bool
+	settings *indexSettings
+	mappings *mapping
+	aliases  []*alias
+}
+
+// NewRollover creates and initializes a new rollover request for the
+// alias or data stream.
+func NewRollover(alias string) *rollover {
+	return &rollover{alias: alias}
+}
+
+// NewIndex names the new index instead of incrementing the old name.
+func (r *rollover) NewIndex(name string) *rollover {
+	r.newIndex = name
+	return r
+}
+
+// MaxAge rolls over when the index is older than maxAge, e.g. "7d".
+func (r *rollover) MaxAge(maxAge string) *rollover {
+	r.maxAge = maxAge
+	return r
+}
+
+// MaxDocs rolls over when the index holds at least maxDocs documents.
+func (r *rollover) MaxDocs(maxDocs int64) *rollover {
+	r.maxDocs = &maxDocs
+	return r
+}
+
+// MaxSize rolls over when the primary shards reach maxSize, e.g. "50gb".
+func (r *rollover) MaxSize(maxSize string) *rollover {
+	r.maxSize = maxSize
+	return r
+}
+
+// MaxPrimaryShardSize rolls over when the largest primary shard reaches
+// maxSize.
+func (r *rollover) MaxPrimaryShardSize(maxSize string) *rollover {
+	r.maxPrimaryShardSize = maxSize
+	return r
+}
+
+// DryRun checks the conditions without rolling over.
+func (r *rollover) DryRun(dryRun bool) *rollover {
+	r.dryRun = dryRun
+	return r
+}
+
+// Settings sets the settings of the new index.
+func (r *rollover) Settings(settings *indexSettings) *rollover {
+	r.settings = settings
+	return r
+}
+
+// Mappings sets the mapping of the new index.
+func (r *rollover) Mappings(mappings *mapping) *rollover {
+	r.mappings = mappings
+	return r
+}
+
+// Alias adds aliases to the new index.
+func (r *rollover) Alias(aliases ...*alias) *rollover {
+	r.aliases = append(r.aliases, aliases...)
+	return r
+}
+
+// Build returns the body of the rollover request.
+func (r *rollover) Build() (interface{}, error) {
+	if r.alias == "" {
+		return nil, fmt.Errorf("rollover: alias must be set")
+	}
+	source := make(map[string]interface{})
+	conditions := make(map[string]interface{})
+	if err := r.build(conditions); err != nil {
+		return nil, fmt.Errorf("rollover: %v", err)
+	}
+	if len(conditions) > 0 {
+		source["conditions"] = conditions
+	}
+	index := NewCreateIndex(r.alias).Settings(r.settings).Mappings(r.mappings).Alias(r.aliases...)
+	src, err := index.Build()
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range src.(map[string]interface{}) {
+		source[key] = value
+	}
+	return source, nil
+}
+
+// Path returns the path of the rollover request.
+func (r *rollover) Path() string {
+	path := url.PathEscape(r.alias) + "/_rollover"
+	if r.newIndex != "" {
+		path += "/" + url.PathEscape(r.newIndex)
+	}
+	if r.dryRun {
+		path += "?dry_run=true"
+	}
+	return path
+}
+
+// Do sends the rollover request.
+func (r *rollover) Do(ctx context.Context, t Transport) (*rolloverResponse, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	src, err := r.Build()
+	if err != nil {
+		return nil, err
+	}
+	data, err := perform(ctx, t, "POST", r.Path(), src)
+	if err != nil {
+		return nil, err
+	}
+	resp := &rolloverResponse{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type rolloverResponse struct {
+	Acknowledged       bool            `json:"acknowledged"`
+	ShardsAcknowledged bool            `json:"shards_acknowledged"`
+	OldIndex           string          `json:"old_index"`
+	NewIndex           string          `json:"new_index"`
+	RolledOver         bool            `json:"rolled_over"`
+	DryRun             bool            `json:"dry_run"`
+	Conditions         map[string]bool `json:"conditions"`
+}
@@ -0,0 +1,56 @@
+package esbuilder
+
+import "fmt"
+
+// runtimeField is a field evaluated at query time.
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.11/runtime.html
+type runtimeField struct {
+	fieldType string
+	script    *script
+	format    string
+}
+
+// NewRuntimeField creates and initializes a new runtimeField of type
+// keyword, long, double, date, boolean, ip or geo_point.
+func NewRuntimeField(fieldType string) *runtimeField {
+	return &runtimeField{fieldType: fieldType}
+}
+
+// Script sets the Painless script emitting the field values. Without a
+// script the value is read from _source.
+func (f *runtimeField) Script(script *script) *runtimeField {
+	f.script = script
+	return f
+}
+
+// Format sets the date format of a date runtime field.
+func (f *runtimeField) Format(format string) *runtimeField {
+	f.format = format
+	return f
+}
+
+func (f *runtimeField) Build() (interface{}, error) {
+	switch f.fieldType {
+	case "keyword", "long", "double", "date", "boolean", "ip", "geo_point":
+	default:
+		return nil, fmt.Errorf("runtime field: invalid type %q", f.fieldType)
+	}
+	if f.format != "" && f.fieldType != "date" {
+		return nil, fmt.Errorf("runtime field: format is only supported by date fields")
+	}
+	source := map[string]interface{}{
+		"type": f.fieldType,
+	}
+	if f.script != nil {
+		script, err := f.script.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["script"] = script
+	}
+	if f.format != "" {
+		source["format"] = f.format
+	}
+	return source, nil
+}
@@ -0,0 +1,270 @@
+package esbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/modules-scripting-using.html
+type script struct {
+	source  string
+	id      string
+	lang    string
+	params  map[string]interface{}
+	options map[string]string
+}
+
+// NewScript creates and initializes a new inline script.
+func NewScript(source string) *script {
+	return &script{source: source}
+}
+
+// NewStoredScript creates and initializes a reference to a stored script.
+func NewStoredScript(id string) *script {
+	return &script{id: id}
+}
+
+// Lang sets the script language, defaults to painless.
+func (s *script) Lang(lang string) *script {
+	s.lang = lang
+	return s
+}
+
+// Param sets a script parameter.
+func (s *script) Param(name string, value interface{}) *script {
+	if s.params == nil {
+		s.params = make(map[string]interface{})
+	}
+	s.params[name] = value
+	return s
+}
+
+// Params sets all script parameters at once.
+func (s *script) Params(params map[string]interface{}) *script {
+	s.params = params
+	return s
+}
+
+// Option sets a script option, e.g. content_type for mustache.
+func (s *script) Option(name, value string) *script {
+	if s.options == nil {
+		s.options = make(map[string]string)
+	}
+	s.options[name] = value
+	return s
+}
+
+// Build returns the map for the script.
+func (s *script) Build() (interface{}, error) {
+	if (s.source == "") == (s.id == "") {
+		return nil, fmt.Errorf("script: exactly one of source and id must be set")
+	}
+	source := make(map[string]interface{})
+	if s.source != "" {
+		source["source"] = s.source
+	} else {
+		source["id"] = s.id
+	}
+	if s.lang != "" {
+		source["lang"] = s.lang
+	}
+	if len(s.params) > 0 {
+		source["params"] = s.params
+	}
+	if len(s.options) > 0 {
+		source["options"] = s.options
+	}
+	return source, nil
+}
+
+var (
+	scriptParamRe = regexp.MustCompile(`\bparams(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[\s*['"]([^'"]+)['"]\s*\])`)
+	scriptDocRe   = regexp.MustCompile(`\bdoc\[\s*['"]([^'"]+)['"]\s*\]`)
+)
+
+// Lint checks an inline Painless script for unbalanced brackets,
+// parameters that are referenced but not supplied and, if fields are
+// given, doc['field'] accesses to fields that are not declared.
+// Stored scripts are not checked.
+func (s *script) Lint(fields ...string) []error {
+	errs := make([]error, 0)
+	if s.source == "" {
+		return errs
+	}
+	if err := checkScriptBrackets(s.source); err != nil {
+		errs = append(errs, err)
+	}
+
+	code := stripScriptComments(s.source)
+	missing := make(map[string]bool)
+	for _, m := range scriptParamRe.FindAllStringSubmatch(code, -1) {
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
+		if _, ok := s.params[name]; !ok {
+			missing[name] = true
+		}
+	}
+	for _, name := range sortedKeys(missing) {
+		errs = append(errs, fmt.Errorf("script: params.%s is referenced but not supplied", name))
+	}
+
+	if len(fields) > 0 {
+		declared := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			declared[field] = true
+		}
+		unknown := make(map[string]bool)
+		for _, m := range scriptDocRe.FindAllStringSubmatch(code, -1) {
+			if !declared[m[1]] {
+				unknown[m[1]] = true
+			}
+		}
+		for _, field := range sortedKeys(unknown) {
+			errs = append(errs, fmt.Errorf("script: doc['%s'] uses a field that is not declared", field))
+		}
+	}
+	return errs
+}
+
+// checkScriptBrackets reports unbalanced (), [] and {} outside of
+// string literals and comments.
+func checkScriptBrackets(source string) error {
+	code := []rune(stripScriptComments(source))
+	pairs := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var stack []rune
+	var quote rune
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '(', '[', '{':
+			stack = append(stack, c)
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[c] {
+				return fmt.Errorf("script: unexpected %q at offset %d", c, i)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("script: unterminated string literal")
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("script: unclosed %q", stack[len(stack)-1])
+	}
+	return nil
+}
+
+// stripScriptComments replaces // and /* */ comments outside of string
+// literals with spaces.
+func stripScriptComments(source string) string {
+	code := []rune(source)
+	out := make([]rune, 0, len(code))
+	var quote rune
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if quote != 0 {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(code) {
+				i++
+				out = append(out, code[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+			out = append(out, c)
+			continue
+		}
+		if c == '/' && i+1 < len(code) && code[i+1] == '/' {
+			for i < len(code) && code[i] != '\n' {
+				i++
+			}
+			out = append(out, '\n')
+			continue
+		}
+		if c == '/' && i+1 < len(code) && code[i+1] == '*' {
+			i += 2
+			for i+1 < len(code) && !(code[i] == '*' && code[i+1] == '/') {
+				i++
+			}
+			i++
+			out = append(out, ' ')
+			continue
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/query-dsl-script-query.html
+type scriptQuery struct {
+	script    *script
+	queryName string
+	boost     *float64
+}
+
+// NewScriptQuery creates and initializes a new scriptQuery.
+func NewScriptQuery(script *script) *scriptQuery {
+	return &scriptQuery{script: script}
+}
+
+// QueryName sets the query name for the filter.
+func (q *scriptQuery) QueryName(queryName string) *scriptQuery {
+	q.queryName = queryName
+	return q
+}
+
+// Boost sets the boost for this query.
+func (q *scriptQuery) Boost(boost float64) *scriptQuery {
+	q.boost = &boost
+	return q
+}
+
+// Build returns the map for the script query.
+func (q *scriptQuery) Build() (interface{}, error) {
+	if q.script == nil {
+		return nil, fmt.Errorf("script query: script must be set")
+	}
+	src, err := q.script.Build()
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{
+		"script": src,
+	}
+	if q.queryName != "" {
+		params["_name"] = q.queryName
+	}
+	if q.boost != nil {
+		params["boost"] = *q.boost
+	}
+	return map[string]interface{}{
+		"script": params,
+	}, nil
+}
@@ -0,0 +1,39 @@
+package esbuilder
+
+import "fmt"
+
+// scriptField returns a value computed with a script for each hit.
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/search-fields.html#script-fields
+type scriptField struct {
+	script        *script
+	ignoreFailure *bool
+}
+
+// NewScriptField creates and initializes a new scriptField.
+func NewScriptField(script *script) *scriptField {
+	return &scriptField{script: script}
+}
+
+// IgnoreFailure ignores script errors for documents missing a field.
+func (f *scriptField) IgnoreFailure(ignoreFailure bool) *scriptField {
+	f.ignoreFailure = &ignoreFailure
+	return f
+}
+
+func (f *scriptField) Build() (interface{}, error) {
+	if f.script == nil {
+		return nil, fmt.Errorf("script_fields: script must be set")
+	}
+	script, err := f.script.Build()
+	if err != nil {
+		return nil, err
+	}
+	source := map[string]interface{}{
+		"script": script,
+	}
+	if f.ignoreFailure != nil {
+		source["ignore_failure"] = *f.ignoreFailure
+	}
+	return source, nil
+}
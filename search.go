@@ -0,0 +1,237 @@
+package esbuilder
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Search runs dsl against index and decodes the response.
+func Search(ctx context.Context, t Transport, index string, dsl *dsl) (*searchResponse, error) {
+	src, err := dsl.Build()
+	if err != nil {
+		return nil, err
+	}
+	path := "_search"
+	if index != "" {
+		path = url.PathEscape(index) + "/_search"
+	}
+	if params := dsl.URLParams(); len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	data, err := perform(ctx, t, "POST", path, src)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSearchResponse(data)
+}
+
+// DidYouMean runs dsl against index. If no document matches, the top
+// option of every entry of the named suggester replaces the misspelled
+// text in the query and the corrected search is issued once more.
+// The returned string is the correction that was applied, or empty if
+// the first search was returned.
+func DidYouMean(ctx context.Context, t Transport, index string, dsl *dsl, suggestName string) (*searchResponse, string, error) {
+	resp, err := Search(ctx, t, index, dsl)
+	if err != nil {
+		return nil, "", err
+	}
+	// The total is absent when track_total_hits is false, the hits are
+	// empty with size 0.
+	if dsl.QueryDsl == nil || resp.Hits != nil && (resp.TotalHits() > 0 || len(resp.Hits.Hits) > 0) {
+		return resp, "", nil
+	}
+	entries := resp.Suggest[suggestName]
+	replacements := make(map[string]string)
+	var corrected []string
+	for _, entry := range entries {
+		if len(entry.Options) == 0 {
+			corrected = append(corrected, entry.Text)
+			continue
+		}
+		replacements[entry.Text] = entry.Options[0].Text
+		corrected = append(corrected, entry.Options[0].Text)
+	}
+	if len(replacements) == 0 {
+		return resp, "", nil
+	}
+
+	src, err := dsl.QueryDsl.Build()
+	if err != nil {
+		return nil, "", err
+	}
+	retry := *dsl
+	retry.QueryDsl = NewRawQuery(replaceText(src, newTextReplacers(replacements)))
+	retry.Suggest = nil
+	resp, err = Search(ctx, t, index, &retry)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, strings.Join(corrected, " "), nil
+}
+
+// textQueries are the queries whose text DidYouMean corrects.
+var textQueries = []string{"match", "match_phrase", "multi_match", "query_string", "simple_query_string"}
+
+// textReplacer substitutes whole tokens, case-insensitively.
+type textReplacer struct {
+	re *regexp.Regexp
+	to string
+}
+
+func newTextReplacers(replacements map[string]string) []*textReplacer {
+	froms := make([]string, 0, len(replacements))
+	for from := range replacements {
+		if from != "" {
+			froms = append(froms, from)
+		}
+	}
+	sort.Strings(froms)
+	replacers := make([]*textReplacer, 0, len(froms))
+	for _, from := range froms {
+		replacers = append(replacers, &textReplacer{
+			re: regexp.MustCompile("(?i)" + regexp.QuoteMeta(from)),
+			to: replacements[from],
+		})
+	}
+	return replacers
+}
+
+// replace substitutes the matches of r in text that start and end on a
+// token boundary.
+func (r *textReplacer) replace(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range r.re.FindAllStringIndex(text, -1) {
+		if !isTokenBoundary(text, loc[0]) || !isTokenBoundary(text, loc[1]) {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(r.to)
+		last = loc[1]
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isTokenBoundary reports whether a token may start or end at offset i of
+// text. Like the standard tokenizer, it treats each ideograph as a token.
+func isTokenBoundary(text string, i int) bool {
+	if i == 0 || i == len(text) {
+		return true
+	}
+	before, _ := utf8.DecodeLastRuneInString(text[:i])
+	after, _ := utf8.DecodeRuneInString(text[i:])
+	return !isWordRune(before) || !isWordRune(after) || isIdeograph(before) || isIdeograph(after)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// replaceText returns a copy of src with the text of the full-text queries
+// corrected by replacers. Other values, such as term values or analyzer
+// names, are left unchanged.
+func replaceText(src interface{}, replacers []*textReplacer) interface{} {
+	switch v := src.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if params, ok := value.(map[string]interface{}); ok && containsString(textQueries, key) {
+				m[key] = replaceQueryText(key, params, replacers)
+				continue
+			}
+			m[key] = replaceText(value, replacers)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, value := range v {
+			s[i] = replaceText(value, replacers)
+		}
+		return s
+	}
+	return src
+}
+
+// replaceQueryText corrects the text of the params of a textQueries query:
+// {"query": text} for multi_match and query_string, {field: text} or
+// {field: {"query": text}} for match and match_phrase.
+func replaceQueryText(name string, params map[string]interface{}, replacers []*textReplacer) map[string]interface{} {
+	m := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		m[key] = value
+	}
+	fieldLevel := name == "match" || name == "match_phrase"
+	for key, value := range params {
+		switch {
+		case !fieldLevel && key == "query":
+			m[key] = replaceString(value, replacers)
+		case fieldLevel:
+			if field, ok := value.(map[string]interface{}); ok {
+				copied := make(map[string]interface{}, len(field))
+				for k, v := range field {
+					copied[k] = v
+				}
+				copied["query"] = replaceString(field["query"], replacers)
+				m[key] = copied
+			} else {
+				m[key] = replaceString(value, replacers)
+			}
+		}
+	}
+	return m
+}
+
+func replaceString(value interface{}, replacers []*textReplacer) interface{} {
+	text, ok := value.(string)
+	if !ok {
+		return value
+	}
+	for _, r := range replacers {
+		text = r.replace(text)
+	}
+	return text
+}
+
+// Count returns the number of documents of index matching q, or all
+// documents if q is nil.
+func Count(ctx context.Context, t Transport, index string, q query) (int64, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	var src interface{}
+	if q != nil {
+		built, err := q.Build()
+		if err != nil {
+			return 0, err
+		}
+		src = map[string]interface{}{"query": built}
+	}
+	path := "_count"
+	if index != "" {
+		path = url.PathEscape(index) + "/_count"
+	}
+	data, err := perform(ctx, t, "POST", path, src)
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
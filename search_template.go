@@ -0,0 +1,145 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/search-template.html
+type searchTemplate struct {
+	id      string
+	source  string
+	params  map[string]interface{}
+	explain bool
+	profile bool
+}
+
+// NewSearchTemplate creates a search template referencing a stored template.
+func NewSearchTemplate(id string) *searchTemplate {
+	return &searchTemplate{id: id, params: make(map[string]interface{})}
+}
+
+// NewInlineSearchTemplate creates a search template with an inline
+// mustache source.
+func NewInlineSearchTemplate(source string) *searchTemplate {
+	return &searchTemplate{source: source, params: make(map[string]interface{})}
+}
+
+// Param sets a template parameter.
+func (t *searchTemplate) Param(name string, value interface{}) *searchTemplate {
+	t.params[name] = value
+	return t
+}
+
+// Params sets all template parameters at once.
+func (t *searchTemplate) Params(params map[string]interface{}) *searchTemplate {
+	t.params = params
+	return t
+}
+
+// Explain returns detailed information about score computation.
+func (t *searchTemplate) Explain(explain bool) *searchTemplate {
+	t.explain = explain
+	return t
+}
+
+// Profile returns timing information about the search execution.
+func (t *searchTemplate) Profile(profile bool) *searchTemplate {
+	t.profile = profile
+	return t
+}
+
+// Build returns the body of a _search/template request.
+func (t *searchTemplate) Build() (interface{}, error) {
+	source, err := t.buildRender()
+	if err != nil {
+		return nil, err
+	}
+	if t.explain {
+		source["explain"] = true
+	}
+	if t.profile {
+		source["profile"] = true
+	}
+	return source, nil
+}
+
+// BuildRender returns the body of a _render/template request.
+func (t *searchTemplate) BuildRender() (interface{}, error) {
+	return t.buildRender()
+}
+
+func (t *searchTemplate) buildRender() (map[string]interface{}, error) {
+	if (t.id == "") == (t.source == "") {
+		return nil, fmt.Errorf("search template: exactly one of id and source must be set")
+	}
+	source := make(map[string]interface{})
+	if t.id != "" {
+		source["id"] = t.id
+	} else {
+		source["source"] = t.source
+	}
+	if len(t.params) > 0 {
+		source["params"] = t.params
+	}
+	return source, nil
+}
+
+// Render renders an inline template locally and decodes the resulting
+// search body.
+func (t *searchTemplate) Render() (map[string]interface{}, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if t.source == "" {
+		return nil, fmt.Errorf("search template: only inline templates can be rendered locally")
+	}
+	text, err := RenderMustache(t.source, t.params)
+	if err != nil {
+		return nil, err
+	}
+	body := make(map[string]interface{})
+	if err := json.UnmarshalFromString(text, &body); err != nil {
+		return nil, fmt.Errorf("search template: rendered template is not valid JSON: %v", err)
+	}
+	return body, nil
+}
+
+// SearchTemplate runs a search template against index.
+func SearchTemplate(ctx context.Context, t Transport, index string, tmpl *searchTemplate) (*searchResponse, error) {
+	src, err := tmpl.Build()
+	if err != nil {
+		return nil, err
+	}
+	path := "_search/template"
+	if index != "" {
+		path = url.PathEscape(index) + "/_search/template"
+	}
+	data, err := perform(ctx, t, "POST", path, src)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSearchResponse(data)
+}
+
+// RenderTemplate renders a template on the cluster and returns the search body.
+func RenderTemplate(ctx context.Context, t Transport, tmpl *searchTemplate) (map[string]interface{}, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	src, err := tmpl.BuildRender()
+	if err != nil {
+		return nil, err
+	}
+	data, err := perform(ctx, t, "POST", "_render/template", src)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		TemplateOutput map[string]interface{} `json:"template_output"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return resp.TemplateOutput, nil
+}
@@ -1,10 +1,21 @@
 package esbuilder
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/sort-search-results.html
 type sortQuery struct {
-	name  string
-	order string
+	name         string
+	order        string
+	missing      interface{}
+	mode         string
+	unmappedType string
+	numericType  string
+	format       string
+	nested       *nestedSort
 }
 
 func NewSortQuery(name, order string) *sortQuery {
@@ -14,13 +25,254 @@ func NewSortQuery(name, order string) *sortQuery {
 	}
 }
 
+// NewScoreSort sorts by relevance score.
+func NewScoreSort(order string) *sortQuery {
+	return NewSortQuery("_score", order)
+}
+
+// NewDocSort sorts by index order.
+func NewDocSort(order string) *sortQuery {
+	return NewSortQuery("_doc", order)
+}
+
+// Missing sets how documents missing the field are sorted:
+// "_last" (default), "_first" or a custom value.
+func (s *sortQuery) Missing(missing interface{}) *sortQuery {
+	s.missing = missing
+	return s
+}
+
+// Mode selects the value used for multi-valued fields: min, max, sum,
+// avg or median.
+func (s *sortQuery) Mode(mode string) *sortQuery {
+	s.mode = mode
+	return s
+}
+
+// UnmappedType sets the type used for indices where the field is unmapped.
+func (s *sortQuery) UnmappedType(unmappedType string) *sortQuery {
+	s.unmappedType = unmappedType
+	return s
+}
+
+// NumericType casts values to double, long, date or date_nanos when
+// sorting across indices with different mappings.
+func (s *sortQuery) NumericType(numericType string) *sortQuery {
+	s.numericType = numericType
+	return s
+}
+
+// Format sets the date format of the sort values for date fields.
+func (s *sortQuery) Format(format string) *sortQuery {
+	s.format = format
+	return s
+}
+
+// Nested sorts by a field inside nested objects.
+func (s *sortQuery) Nested(nested *nestedSort) *sortQuery {
+	s.nested = nested
+	return s
+}
+
 func (s *sortQuery) Build() (interface{}, error) {
 	source := make(map[string]interface{})
 	if s.name == "" || s.order == "" {
 		return source, fmt.Errorf("name and order must be set")
 	}
-	source[s.name] = map[string]interface{}{
-		"order": s.order,
+	order, err := normalizeSortOrder(s.order)
+	if err != nil {
+		return source, err
+	}
+	params := map[string]interface{}{
+		"order": order,
 	}
+	if s.name == "_score" || s.name == "_doc" {
+		if s.missing != nil || s.mode != "" || s.unmappedType != "" || s.numericType != "" || s.format != "" || s.nested != nil {
+			return source, fmt.Errorf("sort %s only supports order", s.name)
+		}
+		source[s.name] = params
+		return source, nil
+	}
+	if s.missing != nil {
+		params["missing"] = s.missing
+	}
+	if s.mode != "" {
+		if err := validateSortMode(s.mode); err != nil {
+			return source, err
+		}
+		params["mode"] = s.mode
+	}
+	if s.unmappedType != "" {
+		params["unmapped_type"] = s.unmappedType
+	}
+	if s.numericType != "" {
+		switch s.numericType {
+		case "double", "long", "date", "date_nanos":
+		default:
+			return source, fmt.Errorf("invalid numeric_type %q", s.numericType)
+		}
+		params["numeric_type"] = s.numericType
+	}
+	if s.format != "" {
+		params["format"] = s.format
+	}
+	if s.nested != nil {
+		src, err := s.nested.Build()
+		if err != nil {
+			return source, err
+		}
+		params["nested"] = src
+	}
+	source[s.name] = params
 	return source, nil
 }
+
+// normalizeSortOrder returns order in lower case, which is how
+// Elasticsearch compares it.
+func normalizeSortOrder(order string) (string, error) {
+	lower := strings.ToLower(order)
+	if lower != "asc" && lower != "desc" {
+		return "", fmt.Errorf("invalid sort order %q", order)
+	}
+	return lower, nil
+}
+
+func validateSortMode(mode string) error {
+	switch mode {
+	case "min", "max", "sum", "avg", "median":
+		return nil
+	}
+	return fmt.Errorf("invalid sort mode %q", mode)
+}
+
+// nestedSort describes the nested object a sort field belongs to.
+type nestedSort struct {
+	path        string
+	filterItem  query
+	maxChildren *int
+	nested      *nestedSort
+}
+
+// NewNestedSort creates and initializes a new nestedSort.
+func NewNestedSort(path string) *nestedSort {
+	return &nestedSort{path: path}
+}
+
+// Filter restricts the nested objects taken into account when sorting.
+func (n *nestedSort) Filter(filter query) *nestedSort {
+	n.filterItem = filter
+	return n
+}
+
+// MaxChildren is the maximum number of children considered per root document.
+func (n *nestedSort) MaxChildren(maxChildren int) *nestedSort {
+	n.maxChildren = &maxChildren
+	return n
+}
+
+// Nested sets a nested sort inside this nested object.
+func (n *nestedSort) Nested(nested *nestedSort) *nestedSort {
+	n.nested = nested
+	return n
+}
+
+func (n *nestedSort) Build() (interface{}, error) {
+	if n.path == "" {
+		return nil, fmt.Errorf("nested sort: path must be set")
+	}
+	source := map[string]interface{}{
+		"path": n.path,
+	}
+	if n.filterItem != nil {
+		src, err := n.filterItem.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["filter"] = src
+	}
+	if n.maxChildren != nil {
+		source["max_children"] = *n.maxChildren
+	}
+	if n.nested != nil {
+		src, err := n.nested.Build()
+		if err != nil {
+			return nil, err
+		}
+		source["nested"] = src
+	}
+	return source, nil
+}
+
+// scriptSort sorts by values computed with a script.
+type scriptSort struct {
+	scriptType string
+	script     *script
+	order      string
+	mode       string
+	nested     *nestedSort
+}
+
+// NewScriptSort creates a _script sort of type number, string or version.
+func NewScriptSort(scriptType string, script *script) *scriptSort {
+	return &scriptSort{scriptType: scriptType, script: script}
+}
+
+// Order is asc or desc.
+func (s *scriptSort) Order(order string) *scriptSort {
+	s.order = order
+	return s
+}
+
+// Mode selects the value used for multi-valued results.
+func (s *scriptSort) Mode(mode string) *scriptSort {
+	s.mode = mode
+	return s
+}
+
+// Nested sorts by script values computed on nested objects.
+func (s *scriptSort) Nested(nested *nestedSort) *scriptSort {
+	s.nested = nested
+	return s
+}
+
+func (s *scriptSort) Build() (interface{}, error) {
+	switch s.scriptType {
+	case "number", "string", "version":
+	default:
+		return nil, fmt.Errorf("invalid _script sort type %q", s.scriptType)
+	}
+	if s.script == nil {
+		return nil, fmt.Errorf("_script sort: script must be set")
+	}
+	script, err := s.script.Build()
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{
+		"type":   s.scriptType,
+		"script": script,
+	}
+	if s.order != "" {
+		order, err := normalizeSortOrder(s.order)
+		if err != nil {
+			return nil, err
+		}
+		params["order"] = order
+	}
+	if s.mode != "" {
+		if err := validateSortMode(s.mode); err != nil {
+			return nil, err
+		}
+		params["mode"] = s.mode
+	}
+	if s.nested != nil {
+		src, err := s.nested.Build()
+		if err != nil {
+			return nil, err
+		}
+		params["nested"] = src
+	}
+	return map[string]interface{}{
+		"_script": params,
+	}, nil
+}
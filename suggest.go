@@ -0,0 +1,705 @@
+package esbuilder
+
+import "fmt"
+
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.10/search-suggesters.html
+type suggest struct {
+	text       string
+	suggesters []suggester
+}
+
+// suggester is a named term, phrase or completion suggester.
+type suggester interface {
+	query
+	suggestName() string
+}
+
+// NewSuggest creates and initializes a new suggest section.
+func NewSuggest(suggesters ...suggester) *suggest {
+	return &suggest{
+		suggesters: append(make([]suggester, 0), suggesters...),
+	}
+}
+
+// Text sets the global suggest text shared by suggesters without their own text.
+func (s *suggest) Text(text string) *suggest {
+	s.text = text
+	return s
+}
+
+// Add adds suggesters to the section.
+func (s *suggest) Add(suggesters ...suggester) *suggest {
+	s.suggesters = append(s.suggesters, suggesters...)
+	return s
+}
+
+// Build returns the map for the suggest section.
+func (s *suggest) Build() (interface{}, error) {
+	source := make(map[string]interface{})
+	if s.text != "" {
+		source["text"] = s.text
+	}
+	for _, sg := range s.suggesters {
+		name := sg.suggestName()
+		if name == "" || name == "text" {
+			return nil, fmt.Errorf("suggest: invalid suggester name %q", name)
+		}
+		if _, ok := source[name]; ok {
+			return nil, fmt.Errorf("suggest: duplicate suggester name %q", name)
+		}
+		src, err := sg.Build()
+		if err != nil {
+			return nil, err
+		}
+		source[name] = src
+	}
+	return source, nil
+}
+
+// termSuggester suggests terms based on edit distance.
+type termSuggester struct {
+	name           string
+	text           string
+	field          string
+	analyzer       string
+	size           *int
+	sort           string
+	suggestMode    string
+	maxEdits       *int
+	prefixLength   *int
+	minWordLength  *int
+	shardSize      *int
+	stringDistance string
+}
+
+// NewTermSuggester creates and initializes a new termSuggester.
+func NewTermSuggester(name, field string) *termSuggester {
+	return &termSuggester{name: name, field: field}
+}
+
+func (s *termSuggester) suggestName() string {
+	return s.name
+}
+
+// Text sets the text to make suggestions for.
+func (s *termSuggester) Text(text string) *termSuggester {
+	s.text = text
+	return s
+}
+
+// Analyzer analyzes the suggest text. Defaults to the search analyzer of the field.
+func (s *termSuggester) Analyzer(analyzer string) *termSuggester {
+	s.analyzer = analyzer
+	return s
+}
+
+// Size is the maximum number of corrections returned per token.
+func (s *termSuggester) Size(size int) *termSuggester {
+	s.size = &size
+	return s
+}
+
+// Sort is score (default) or frequency.
+func (s *termSuggester) Sort(sort string) *termSuggester {
+	s.sort = sort
+	return s
+}
+
+// SuggestMode is missing (default), popular or always.
+func (s *termSuggester) SuggestMode(suggestMode string) *termSuggester {
+	s.suggestMode = suggestMode
+	return s
+}
+
+// MaxEdits is the maximum edit distance, 1 or 2.
+func (s *termSuggester) MaxEdits(maxEdits int) *termSuggester {
+	s.maxEdits = &maxEdits
+	return s
+}
+
+// PrefixLength is the number of prefix characters that must match.
+func (s *termSuggester) PrefixLength(prefixLength int) *termSuggester {
+	s.prefixLength = &prefixLength
+	return s
+}
+
+// MinWordLength is the minimum length a suggest text term must have.
+func (s *termSuggester) MinWordLength(minWordLength int) *termSuggester {
+	s.minWordLength = &minWordLength
+	return s
+}
+
+// ShardSize is the maximum number of suggestions retrieved from each shard.
+func (s *termSuggester) ShardSize(shardSize int) *termSuggester {
+	s.shardSize = &shardSize
+	return s
+}
+
+// StringDistance is internal (default), damerau_levenshtein, levenshtein,
+// jaro_winkler or ngram.
+func (s *termSuggester) StringDistance(stringDistance string) *termSuggester {
+	s.stringDistance = stringDistance
+	return s
+}
+
+func (s *termSuggester) Build() (interface{}, error) {
+	if s.field == "" {
+		return nil, fmt.Errorf("term suggester %s: field must be set", s.name)
+	}
+	params := map[string]interface{}{
+		"field": s.field,
+	}
+	if s.analyzer != "" {
+		params["analyzer"] = s.analyzer
+	}
+	if s.size != nil {
+		params["size"] = *s.size
+	}
+	if s.sort != "" {
+		params["sort"] = s.sort
+	}
+	if s.suggestMode != "" {
+		params["suggest_mode"] = s.suggestMode
+	}
+	if s.maxEdits != nil {
+		if *s.maxEdits < 1 || *s.maxEdits > 2 {
+			return nil, fmt.Errorf("term suggester %s: max_edits must be 1 or 2", s.name)
+		}
+		params["max_edits"] = *s.maxEdits
+	}
+	if s.prefixLength != nil {
+		params["prefix_length"] = *s.prefixLength
+	}
+	if s.minWordLength != nil {
+		params["min_word_length"] = *s.minWordLength
+	}
+	if s.shardSize != nil {
+		params["shard_size"] = *s.shardSize
+	}
+	if s.stringDistance != "" {
+		params["string_distance"] = s.stringDistance
+	}
+	source := map[string]interface{}{
+		"term": params,
+	}
+	if s.text != "" {
+		source["text"] = s.text
+	}
+	return source, nil
+}
+
+// phraseSuggester suggests corrections for whole phrases.
+type phraseSuggester struct {
+	name                    string
+	text                    string
+	field                   string
+	analyzer                string
+	gramSize                *int
+	realWordErrorLikelihood *float64
+	confidence              *float64
+	maxErrors               *float64
+	separator               string
+	size                    *int
+	shardSize               *int
+	preTag                  string
+	postTag                 string
+	collateQuery            query
+	collateParams           map[string]interface{}
+	collatePrune            *bool
+	directGenerators        []*directGenerator
+	smoothing               query
+}
+
+// NewPhraseSuggester creates and initializes a new phraseSuggester.
+func NewPhraseSuggester(name, field string) *phraseSuggester {
+	return &phraseSuggester{
+		name:             name,
+		field:            field,
+		directGenerators: make([]*directGenerator, 0),
+	}
+}
+
+func (s *phraseSuggester) suggestName() string {
+	return s.name
+}
+
+// Text sets the text to make suggestions for.
+func (s *phraseSuggester) Text(text string) *phraseSuggester {
+	s.text = text
+	return s
+}
+
+// Analyzer analyzes the suggest text.
+func (s *phraseSuggester) Analyzer(analyzer string) *phraseSuggester {
+	s.analyzer = analyzer
+	return s
+}
+
+// GramSize is the maximum size of the n-grams in the field.
+func (s *phraseSuggester) GramSize(gramSize int) *phraseSuggester {
+	s.gramSize = &gramSize
+	return s
+}
+
+// RealWordErrorLikelihood is the likelihood of a term being misspelled
+// even if it exists in the dictionary. Defaults to 0.95.
+func (s *phraseSuggester) RealWordErrorLikelihood(likelihood float64) *phraseSuggester {
+	s.realWordErrorLikelihood = &likelihood
+	return s
+}
+
+// Confidence is the factor applied to the input phrase score used as
+// threshold for other suggestions. Defaults to 1.0.
+func (s *phraseSuggester) Confidence(confidence float64) *phraseSuggester {
+	s.confidence = &confidence
+	return s
+}
+
+// MaxErrors is the maximum percentage (< 1) or number (>= 1) of terms
+// considered misspelled.
+func (s *phraseSuggester) MaxErrors(maxErrors float64) *phraseSuggester {
+	s.maxErrors = &maxErrors
+	return s
+}
+
+// Separator separates terms in the bigram field.
+func (s *phraseSuggester) Separator(separator string) *phraseSuggester {
+	s.separator = separator
+	return s
+}
+
+// Size is the number of candidates generated for each query term.
+func (s *phraseSuggester) Size(size int) *phraseSuggester {
+	s.size = &size
+	return s
+}
+
+// ShardSize is the maximum number of suggestions retrieved from each shard.
+func (s *phraseSuggester) ShardSize(shardSize int) *phraseSuggester {
+	s.shardSize = &shardSize
+	return s
+}
+
+// Highlight sets the tags surrounding changed tokens.
+func (s *phraseSuggester) Highlight(preTag, postTag string) *phraseSuggester {
+	s.preTag = preTag
+	s.postTag = postTag
+	return s
+}
+
+// CollateQuery checks each suggestion against query. The query is a
+// mustache template; {{suggestion}} is replaced by each suggestion.
+func (s *phraseSuggester) CollateQuery(query query) *phraseSuggester {
+	s.collateQuery = query
+	return s
+}
+
+// CollateParam sets a parameter of the collate query template.
+func (s *phraseSuggester) CollateParam(name string, value interface{}) *phraseSuggester {
+	if s.collateParams == nil {
+		s.collateParams = make(map[string]interface{})
+	}
+	s.collateParams[name] = value
+	return s
+}
+
+// CollatePrune returns all suggestions with a collate_match flag instead
+// of dropping the ones not matching the collate query.
+func (s *phraseSuggester) CollatePrune(prune bool) *phraseSuggester {
+	s.collatePrune = &prune
+	return s
+}
+
+// DirectGenerator adds candidate generators.
+func (s *phraseSuggester) DirectGenerator(generators ...*directGenerator) *phraseSuggester {
+	s.directGenerators = append(s.directGenerators, generators...)
+	return s
+}
+
+// Smoothing sets the smoothing model, see NewStupidBackoffSmoothing,
+// NewLaplaceSmoothing and NewLinearInterpolationSmoothing.
+func (s *phraseSuggester) Smoothing(smoothing query) *phraseSuggester {
+	s.smoothing = smoothing
+	return s
+}
+
+func (s *phraseSuggester) Build() (interface{}, error) {
+	if s.field == "" {
+		return nil, fmt.Errorf("phrase suggester %s: field must be set", s.name)
+	}
+	params := map[string]interface{}{
+		"field": s.field,
+	}
+	if s.analyzer != "" {
+		params["analyzer"] = s.analyzer
+	}
+	if s.gramSize != nil {
+		params["gram_size"] = *s.gramSize
+	}
+	if s.realWordErrorLikelihood != nil {
+		params["real_word_error_likelihood"] = *s.realWordErrorLikelihood
+	}
+	if s.confidence != nil {
+		params["confidence"] = *s.confidence
+	}
+	if s.maxErrors != nil {
+		params["max_errors"] = *s.maxErrors
+	}
+	if s.separator != "" {
+		params["separator"] = s.separator
+	}
+	if s.size != nil {
+		params["size"] = *s.size
+	}
+	if s.shardSize != nil {
+		params["shard_size"] = *s.shardSize
+	}
+	if s.preTag != "" || s.postTag != "" {
+		params["highlight"] = map[string]interface{}{
+			"pre_tag":  s.preTag,
+			"post_tag": s.postTag,
+		}
+	}
+	if s.collateQuery != nil {
+		src, err := s.collateQuery.Build()
+		if err != nil {
+			return nil, err
+		}
+		collate := map[string]interface{}{
+			"query": map[string]interface{}{
+				"source": src,
+			},
+		}
+		if len(s.collateParams) > 0 {
+			collate["params"] = s.collateParams
+		}
+		if s.collatePrune != nil {
+			collate["prune"] = *s.collatePrune
+		}
+		params["collate"] = collate
+	}
+	if len(s.directGenerators) > 0 {
+		var generators []interface{}
+		for _, g := range s.directGenerators {
+			src, err := g.Build()
+			if err != nil {
+				return nil, err
+			}
+			generators = append(generators, src)
+		}
+		params["direct_generator"] = generators
+	}
+	if s.smoothing != nil {
+		src, err := s.smoothing.Build()
+		if err != nil {
+			return nil, err
+		}
+		params["smoothing"] = src
+	}
+	source := map[string]interface{}{
+		"phrase": params,
+	}
+	if s.text != "" {
+		source["text"] = s.text
+	}
+	return source, nil
+}
+
+// directGenerator generates candidate terms for the phrase suggester.
+type directGenerator struct {
+	field         string
+	size          *int
+	suggestMode   string
+	maxEdits      *int
+	prefixLength  *int
+	minWordLength *int
+	preFilter     string
+	postFilter    string
+}
+
+// NewDirectGenerator creates and initializes a new directGenerator.
+func NewDirectGenerator(field string) *directGenerator {
+	return &directGenerator{field: field}
+}
+
+// Size is the maximum number of corrections returned per token.
+func (g *directGenerator) Size(size int) *directGenerator {
+	g.size = &size
+	return g
+}
+
+// SuggestMode is missing (default), popular or always.
+func (g *directGenerator) SuggestMode(suggestMode string) *directGenerator {
+	g.suggestMode = suggestMode
+	return g
+}
+
+// MaxEdits is the maximum edit distance, 1 or 2.
+func (g *directGenerator) MaxEdits(maxEdits int) *directGenerator {
+	g.maxEdits = &maxEdits
+	return g
+}
+
+// PrefixLength is the number of prefix characters that must match.
+func (g *directGenerator) PrefixLength(prefixLength int) *directGenerator {
+	g.prefixLength = &prefixLength
+	return g
+}
+
+// MinWordLength is the minimum length a suggest text term must have.
+func (g *directGenerator) MinWordLength(minWordLength int) *directGenerator {
+	g.minWordLength = &minWordLength
+	return g
+}
+
+// PreFilter is the analyzer applied to each token before candidates are generated.
+func (g *directGenerator) PreFilter(analyzer string) *directGenerator {
+	g.preFilter = analyzer
+	return g
+}
+
+// PostFilter is the analyzer applied to each generated candidate.
+func (g *directGenerator) PostFilter(analyzer string) *directGenerator {
+	g.postFilter = analyzer
+	return g
+}
+
+func (g *directGenerator) Build() (interface{}, error) {
+	if g.field == "" {
+		return nil, fmt.Errorf("direct_generator: field must be set")
+	}
+	source := map[string]interface{}{
+		"field": g.field,
+	}
+	if g.size != nil {
+		source["size"] = *g.size
+	}
+	if g.suggestMode != "" {
+		source["suggest_mode"] = g.suggestMode
+	}
+	if g.maxEdits != nil {
+		source["max_edits"] = *g.maxEdits
+	}
+	if g.prefixLength != nil {
+		source["prefix_length"] = *g.prefixLength
+	}
+	if g.minWordLength != nil {
+		source["min_word_length"] = *g.minWordLength
+	}
+	if g.preFilter != "" {
+		source["pre_filter"] = g.preFilter
+	}
+	if g.postFilter != "" {
+		source["post_filter"] = g.postFilter
+	}
+	return source, nil
+}
+
+// smoothingModel balances the weight between frequent and infrequent
+// n-grams in the phrase suggester.
+type smoothingModel struct {
+	name   string
+	params map[string]interface{}
+}
+
+// NewStupidBackoffSmoothing creates a stupid_backoff smoothing model.
+func NewStupidBackoffSmoothing(discount float64) *smoothingModel {
+	return &smoothingModel{
+		name:   "stupid_backoff",
+		params: map[string]interface{}{"discount": discount},
+	}
+}
+
+// NewLaplaceSmoothing creates a laplace smoothing model.
+func NewLaplaceSmoothing(alpha float64) *smoothingModel {
+	return &smoothingModel{
+		name:   "laplace",
+		params: map[string]interface{}{"alpha": alpha},
+	}
+}
+
+// NewLinearInterpolationSmoothing creates a linear_interpolation smoothing
+// model. The lambdas must sum up to 1.
+func NewLinearInterpolationSmoothing(trigramLambda, bigramLambda, unigramLambda float64) *smoothingModel {
+	return &smoothingModel{
+		name: "linear_interpolation",
+		params: map[string]interface{}{
+			"trigram_lambda": trigramLambda,
+			"bigram_lambda":  bigramLambda,
+			"unigram_lambda": unigramLambda,
+		},
+	}
+}
+
+func (m *smoothingModel) Build() (interface{}, error) {
+	return map[string]interface{}{
+		m.name: m.params,
+	}, nil
+}
+
+// completionSuggester provides search-as-you-type on completion fields.
+type completionSuggester struct {
+	name           string
+	prefix         string
+	regex          string
+	field          string
+	size           *int
+	skipDuplicates *bool
+	fuzzy          *completionFuzzy
+	contexts       map[string][]interface{}
+}
+
+type completionFuzzy struct {
+	fuzziness      interface{}
+	transpositions *bool
+	minLength      *int
+	prefixLength   *int
+	unicodeAware   *bool
+}
+
+// NewCompletionSuggester creates and initializes a new completionSuggester.
+func NewCompletionSuggester(name, field string) *completionSuggester {
+	return &completionSuggester{name: name, field: field}
+}
+
+func (s *completionSuggester) suggestName() string {
+	return s.name
+}
+
+// Prefix sets the prefix to complete.
+func (s *completionSuggester) Prefix(prefix string) *completionSuggester {
+	s.prefix = prefix
+	return s
+}
+
+// Regex completes using a regular expression instead of a prefix.
+func (s *completionSuggester) Regex(regex string) *completionSuggester {
+	s.regex = regex
+	return s
+}
+
+// Size is the number of suggestions to return. Defaults to 5.
+func (s *completionSuggester) Size(size int) *completionSuggester {
+	s.size = &size
+	return s
+}
+
+// SkipDuplicates filters out suggestions with duplicate text.
+func (s *completionSuggester) SkipDuplicates(skipDuplicates bool) *completionSuggester {
+	s.skipDuplicates = &skipDuplicates
+	return s
+}
+
+// Fuzzy enables fuzzy completion with the given fuzziness, e.g. 1 or "AUTO".
+func (s *completionSuggester) Fuzzy(fuzziness interface{}) *completionSuggester {
+	s.fuzzy = &completionFuzzy{fuzziness: fuzziness}
+	return s
+}
+
+// FuzzyTranspositions counts transpositions as one change. Requires Fuzzy.
+func (s *completionSuggester) FuzzyTranspositions(transpositions bool) *completionSuggester {
+	if s.fuzzy != nil {
+		s.fuzzy.transpositions = &transpositions
+	}
+	return s
+}
+
+// FuzzyMinLength is the minimum input length before fuzzy suggestions are
+// returned. Requires Fuzzy.
+func (s *completionSuggester) FuzzyMinLength(minLength int) *completionSuggester {
+	if s.fuzzy != nil {
+		s.fuzzy.minLength = &minLength
+	}
+	return s
+}
+
+// FuzzyPrefixLength is the minimum input length not checked for fuzzy
+// alternatives. Requires Fuzzy.
+func (s *completionSuggester) FuzzyPrefixLength(prefixLength int) *completionSuggester {
+	if s.fuzzy != nil {
+		s.fuzzy.prefixLength = &prefixLength
+	}
+	return s
+}
+
+// FuzzyUnicodeAware measures edits in unicode code points. Requires Fuzzy.
+func (s *completionSuggester) FuzzyUnicodeAware(unicodeAware bool) *completionSuggester {
+	if s.fuzzy != nil {
+		s.fuzzy.unicodeAware = &unicodeAware
+	}
+	return s
+}
+
+// Context adds values for a category or geo context of the completion field.
+func (s *completionSuggester) Context(name string, values ...interface{}) *completionSuggester {
+	if s.contexts == nil {
+		s.contexts = make(map[string][]interface{})
+	}
+	s.contexts[name] = append(s.contexts[name], values...)
+	return s
+}
+
+func (s *completionSuggester) Build() (interface{}, error) {
+	if s.field == "" {
+		return nil, fmt.Errorf("completion suggester %s: field must be set", s.name)
+	}
+	if s.prefix != "" && s.regex != "" {
+		return nil, fmt.Errorf("completion suggester %s: prefix and regex are exclusive", s.name)
+	}
+	params := map[string]interface{}{
+		"field": s.field,
+	}
+	if s.size != nil {
+		params["size"] = *s.size
+	}
+	if s.skipDuplicates != nil {
+		params["skip_duplicates"] = *s.skipDuplicates
+	}
+	if s.fuzzy != nil {
+		fuzzy := make(map[string]interface{})
+		if s.fuzzy.fuzziness != nil {
+			fuzzy["fuzziness"] = s.fuzzy.fuzziness
+		}
+		if s.fuzzy.transpositions != nil {
+			fuzzy["transpositions"] = *s.fuzzy.transpositions
+		}
+		if s.fuzzy.minLength != nil {
+			fuzzy["min_length"] = *s.fuzzy.minLength
+		}
+		if s.fuzzy.prefixLength != nil {
+			fuzzy["prefix_length"] = *s.fuzzy.prefixLength
+		}
+		if s.fuzzy.unicodeAware != nil {
+			fuzzy["unicode_aware"] = *s.fuzzy.unicodeAware
+		}
+		params["fuzzy"] = fuzzy
+	}
+	if len(s.contexts) > 0 {
+		contexts := make(map[string]interface{})
+		for name, values := range s.contexts {
+			var built []interface{}
+			for _, v := range values {
+				if q, ok := v.(query); ok {
+					src, err := q.Build()
+					if err != nil {
+						return nil, err
+					}
+					v = src
+				}
+				built = append(built, v)
+			}
+			contexts[name] = built
+		}
+		params["contexts"] = contexts
+	}
+	source := map[string]interface{}{
+		"completion": params,
+	}
+	if s.regex != "" {
+		source["regex"] = s.regex
+	} else if s.prefix != "" {
+		source["prefix"] = s.prefix
+	}
+	return source, nil
+}
@@ -0,0 +1,87 @@
+package esbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// taskStatus is the progress of a running by-query or reindex task.
+type taskStatus struct {
+	Total            int64 `json:"total"`
+	Updated          int64 `json:"updated"`
+	Created          int64 `json:"created"`
+	Deleted          int64 `json:"deleted"`
+	Batches          int64 `json:"batches"`
+	VersionConflicts int64 `json:"version_conflicts"`
+	Noops            int64 `json:"noops"`
+}
+
+// Done returns the number of documents processed so far.
+func (s *taskStatus) Done() int64 {
+	return s.Updated + s.Created + s.Deleted + s.VersionConflicts + s.Noops
+}
+
+type taskResponse struct {
+	Completed bool `json:"completed"`
+	Task      struct {
+		Node   string      `json:"node"`
+		Id     int64       `json:"id"`
+		Action string      `json:"action"`
+		Status *taskStatus `json:"status"`
+	} `json:"task"`
+	Response *byQueryResponse `json:"response,omitempty"`
+	Error    interface{}      `json:"error,omitempty"`
+}
+
+// minTaskPollInterval is the interval WaitForTask uses when given a
+// shorter one.
+const minTaskPollInterval = 100 * time.Millisecond
+
+// WaitForTask polls the task with the given id every interval, at least
+// 100ms, until it completes. progress, if not nil, is called with the
+// status after each poll. The final response of the task is returned, or
+// an error if the task failed.
+func WaitForTask(ctx context.Context, t Transport, taskId string, interval time.Duration, progress func(status *taskStatus)) (*byQueryResponse, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if taskId == "" {
+		return nil, fmt.Errorf("task: id must be set")
+	}
+	if interval < minTaskPollInterval {
+		interval = minTaskPollInterval
+	}
+	for {
+		data, err := perform(ctx, t, "GET", "_tasks/"+url.PathEscape(taskId), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp := &taskResponse{}
+		if err := json.Unmarshal(data, resp); err != nil {
+			return nil, err
+		}
+		if progress != nil && resp.Task.Status != nil {
+			progress(resp.Task.Status)
+		}
+		if resp.Completed {
+			if resp.Error != nil {
+				errData, _ := json.Marshal(map[string]interface{}{"error": resp.Error})
+				return resp.Response, newResponseError(500, errData)
+			}
+			if resp.Response == nil {
+				return nil, fmt.Errorf("task %s: completed without response", taskId)
+			}
+			if len(resp.Response.Failures) > 0 {
+				return resp.Response, fmt.Errorf("task %s: %d failures", taskId, len(resp.Response.Failures))
+			}
+			return resp.Response, nil
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
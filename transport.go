@@ -0,0 +1,177 @@
+package esbuilder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Transport sends a request to Elasticsearch and returns the status code
+// and body of the response.
+type Transport interface {
+	Perform(ctx context.Context, method, path string, body []byte) (int, []byte, error)
+}
+
+// TransportFunc adapts a function to the Transport interface.
+type TransportFunc func(ctx context.Context, method, path string, body []byte) (int, []byte, error)
+
+func (f TransportFunc) Perform(ctx context.Context, method, path string, body []byte) (int, []byte, error) {
+	return f(ctx, method, path, body)
+}
+
+type httpTransport struct {
+	baseURL string
+	client  *http.Client
+	header  http.Header
+}
+
+// NewHTTPTransport creates a Transport sending requests to baseURL,
+// e.g. "http://localhost:9200". http.DefaultClient is used if client is nil.
+func NewHTTPTransport(baseURL string, client *http.Client) *httpTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpTransport{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+		header:  make(http.Header),
+	}
+}
+
+// SetHeader sets a header sent with every request, e.g. Authorization.
+func (t *httpTransport) SetHeader(key, value string) *httpTransport {
+	t.header.Set(key, value)
+	return t
+}
+
+func (t *httpTransport) Perform(ctx context.Context, method, path string, body []byte) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.baseURL+"/"+strings.TrimLeft(path, "/"), reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	for key, values := range t.header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		if isNdjsonPath(path) {
+			req.Header.Set("Content-Type", "application/x-ndjson")
+		} else {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	return resp.StatusCode, data, nil
+}
+
+func isNdjsonPath(path string) bool {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	return strings.HasSuffix(path, "_bulk") || strings.HasSuffix(path, "_msearch") ||
+		strings.HasSuffix(path, "_msearch/template")
+}
+
+// ResponseError is returned when Elasticsearch answers with an error status.
+type ResponseError struct {
+	Status int
+	Type   string
+	Reason string
+	Body   []byte
+}
+
+func (e *ResponseError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("elasticsearch: status %d: %s: %s", e.Status, e.Type, e.Reason)
+	}
+	if e.Reason != "" {
+		return fmt.Sprintf("elasticsearch: status %d: %s", e.Status, e.Reason)
+	}
+	return fmt.Sprintf("elasticsearch: status %d: %s", e.Status, string(e.Body))
+}
+
+// newResponseError decodes the error object of an error response.
+func newResponseError(status int, body []byte) *ResponseError {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	e := &ResponseError{Status: status, Body: body}
+	var resp struct {
+		Error interface{} `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) == nil {
+		switch v := resp.Error.(type) {
+		case string:
+			e.Reason = v
+		case map[string]interface{}:
+			e.Type, _ = v["type"].(string)
+			e.Reason, _ = v["reason"].(string)
+		}
+	}
+	return e
+}
+
+// perform sends a JSON body built from src and returns the response body,
+// turning error statuses into a *ResponseError.
+func perform(ctx context.Context, t Transport, method, path string, src interface{}) ([]byte, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	var body []byte
+	if src != nil {
+		var err error
+		body, err = json.Marshal(src)
+		if err != nil {
+			return nil, err
+		}
+	}
+	status, data, err := t.Perform(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	if status < 200 || status > 299 {
+		return nil, newResponseError(status, data)
+	}
+	return data, nil
+}
+
+type dryRunTransport struct {
+	w    io.Writer
+	read Transport
+}
+
+// NewDryRunTransport creates a Transport printing every request to w
+// instead of sending it, answering {} with status 200. GET requests are
+// forwarded to read when it is not nil, so the current state of the
+// cluster can still be inspected.
+func NewDryRunTransport(w io.Writer, read Transport) *dryRunTransport {
+	return &dryRunTransport{w: w, read: read}
+}
+
+func (t *dryRunTransport) Perform(ctx context.Context, method, path string, body []byte) (int, []byte, error) {
+	if _, err := fmt.Fprintf(t.w, "%s /%s\n", method, strings.TrimLeft(path, "/")); err != nil {
+		return 0, nil, err
+	}
+	if len(body) > 0 {
+		text := strings.TrimRight(string(body), "\n")
+		if _, err := fmt.Fprintln(t.w, text); err != nil {
+			return 0, nil, err
+		}
+	}
+	if method == "GET" && t.read != nil {
+		return t.read.Perform(ctx, method, path, body)
+	}
+	return 200, []byte("{}"), nil
+}